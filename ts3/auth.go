@@ -21,11 +21,20 @@ func (c *Client) Login(ctx context.Context, username, password string) error {
 		Escape(username),
 		Escape(password),
 	)
-	_, err := c.Exec(ctx, cmd)
-	return err
+	if _, err := c.Exec(ctx, cmd); err != nil {
+		return err
+	}
+
+	c.updateSession(func(s *sessionState) {
+		s.loggedIn = true
+		s.loginName = username
+		s.loginPassword = password
+	})
+	return nil
 }
 
-// Use selects the target virtual server by server id.
+// Use selects the target virtual server by server id. Active notification
+// scopes are registered again on the new server.
 func (c *Client) Use(ctx context.Context, virtualServerID int) error {
 	if c.isWebQuery() {
 		c.setSelectedSID(virtualServerID)
@@ -33,24 +42,27 @@ func (c *Client) Use(ctx context.Context, virtualServerID int) error {
 	}
 
 	cmd := fmt.Sprintf("use sid=%d", virtualServerID)
-	_, err := c.Exec(ctx, cmd)
-	return err
+	if _, err := c.Exec(ctx, cmd); err != nil {
+		return err
+	}
+
+	c.updateSession(func(s *sessionState) {
+		s.serverID = virtualServerID
+		s.serverPort = 0
+	})
+	return c.reapplyNotify(ctx)
 }
 
 // UseByPort selects the target virtual server by voice port (e.g. 9987).
+// Active notification scopes are registered again on the new server.
 func (c *Client) UseByPort(ctx context.Context, port int) error {
 	if c.isWebQuery() {
-		resp, err := c.Exec(ctx, fmt.Sprintf("serveridgetbyport virtualserver_port=%d", port))
-		if err != nil {
-			return err
-		}
-
-		var out struct {
+		out, err := QueryOne[struct {
 			ServerID  int `ts3:"server_id"`
 			SID       int `ts3:"sid"`
 			ServerID2 int `ts3:"virtualserver_id"`
-		}
-		if err := NewDecoder().Decode(resp, &out); err != nil {
+		}](ctx, c, fmt.Sprintf("serveridgetbyport virtualserver_port=%d", port))
+		if err != nil {
 			return err
 		}
 
@@ -70,8 +82,15 @@ func (c *Client) UseByPort(ctx context.Context, port int) error {
 	}
 
 	cmd := fmt.Sprintf("use port=%d", port)
-	_, err := c.Exec(ctx, cmd)
-	return err
+	if _, err := c.Exec(ctx, cmd); err != nil {
+		return err
+	}
+
+	c.updateSession(func(s *sessionState) {
+		s.serverID = 0
+		s.serverPort = port
+	})
+	return c.reapplyNotify(ctx)
 }
 
 // Logout logs out the current ServerQuery session.
@@ -80,6 +99,29 @@ func (c *Client) Logout(ctx context.Context) error {
 		return nil
 	}
 
-	_, err := c.Exec(ctx, "logout")
-	return err
+	if _, err := c.Exec(ctx, "logout"); err != nil {
+		return err
+	}
+
+	c.updateSession(func(s *sessionState) {
+		*s = sessionState{}
+	})
+	c.resetNotify()
+	return nil
+}
+
+// SetNickname changes the nickname of the current query client.
+func (c *Client) SetNickname(ctx context.Context, nickname string) error {
+	if strings.TrimSpace(nickname) == "" {
+		return errors.New("ts3: nickname is required")
+	}
+
+	if _, err := c.Exec(ctx, "clientupdate client_nickname="+Escape(nickname)); err != nil {
+		return err
+	}
+
+	c.updateSession(func(s *sessionState) {
+		s.nickname = nickname
+	})
+	return nil
 }
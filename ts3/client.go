@@ -2,13 +2,16 @@ package ts3
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"io"
 	"net"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -19,6 +22,7 @@ const (
 	defaultDialTimeout     = 10 * time.Second
 	defaultMaxLineSize     = 1024 * 1024
 	defaultCmdBufSize      = 256
+	defaultDrainGrace      = 5 * time.Second
 )
 
 type clientTransport int
@@ -35,6 +39,49 @@ type Config struct {
 	Timeout         time.Duration
 	KeepAlivePeriod time.Duration
 	MaxLineSize     int
+
+	// Dialer opens the TCP connection for raw and SSH clients, e.g. through a
+	// proxy (see SOCKS5). It is compatible with (*net.Dialer).DialContext.
+	// Nil uses a net.Dialer.
+	Dialer func(ctx context.Context, network, addr string) (net.Conn, error)
+
+	// CommandTimeout bounds commands whose context has no deadline.
+	// 0 disables the default timeout.
+	CommandTimeout time.Duration
+	// DrainGrace is how long a cancelled raw/SSH command keeps waiting for its
+	// reply before the connection is considered out of sync and closed.
+	// Default: 5s.
+	DrainGrace time.Duration
+	// ReadIdleTimeout enables dead-peer detection for raw/SSH clients: when
+	// nothing was received for this long, a probe command is sent, and the
+	// connection is closed if the probe gets no answer within ProbeTimeout
+	// (default 5s). No probe is sent while a command waits for its reply, as
+	// slow commands like serversnapshotcreate may be silent for long; those
+	// are bounded by their context and CommandTimeout instead. 0 disables it.
+	ReadIdleTimeout time.Duration
+	ProbeTimeout    time.Duration
+
+	// FloodLimit configures the client-side flood protection of raw/SSH
+	// clients. The zero value uses TeamSpeak's default flood settings.
+	FloodLimit FloodLimit
+
+	// Reconnect enables automatic redial and session restoration when the
+	// raw/SSH transport drops. Nil disables reconnecting.
+	Reconnect *ReconnectPolicy
+	// OnDisconnect is called when the transport is lost.
+	OnDisconnect func(err error)
+	// OnReconnect is called after the session was restored on a new connection.
+	OnReconnect func(attempt int)
+
+	// EventBuffer is the queue size of Events channels. Default: 256.
+	// Register and On* handlers have unbounded queues.
+	EventBuffer int
+	// EventOverflow applies when an Events channel is full. Default:
+	// OverflowDropOldest.
+	EventOverflow OverflowPolicy
+
+	// Recorder, when set, records notifications and command exchanges.
+	Recorder *Recorder
 }
 
 // Client is a TS3 ServerQuery client.
@@ -43,27 +90,67 @@ type Config struct {
 // per-request IDs. Use one Client instance per connection/session.
 type Client struct {
 	conn    io.ReadWriteCloser
+	connMu  sync.Mutex
 	scanner *bufio.Scanner
 	web     *webQueryRuntime
-
-	mu          sync.Mutex
-	cmdResChan  chan string
-	errorChan   chan error
-	transport   clientTransport
-	selectedSID int
-
-	notifications map[string][]func(string)
-	notifyMu      sync.RWMutex
+	pool    *Pool
+
+	mu           sync.Mutex
+	cmdResChan   chan string
+	errorChan    chan error
+	transport    clientTransport
+	selectedSID  int
+	connGen      uint64
+	reconnecting bool
+	poisoned     bool
+	session      sessionState
+
+	dial         dialFunc
+	reconnect    *ReconnectPolicy
+	onDisconnect func(error)
+	onReconnect  func(int)
+	timeout      time.Duration
+	cmdTimeout   time.Duration
+	drainGrace   time.Duration
+	maxLineSize  int
+	recorder     *Recorder
+	limiter      atomic.Pointer[floodLimiter]
+	lastRecv     atomic.Int64
+	inFlight     atomic.Int32
+
+	state         State
+	stateMu       sync.Mutex
+	stateWatchers map[chan State]func() bool
+
+	events     *Dispatcher
+	notifyRegs []*NotifyRegistration
+	notifyMu   sync.Mutex
 
 	quit      chan struct{}
 	closeOnce sync.Once
 
+	// lifeMu orders execWG.Add against Shutdown. wg tracks every goroutine
+	// started by the client.
+	lifeMu       sync.RWMutex
+	shuttingDown bool
+	execWG       sync.WaitGroup
+	wg           sync.WaitGroup
+
 	logger   Logger
 	loggerMu sync.RWMutex
 }
 
 // NewClient creates a TCP-based TS3 ServerQuery client.
 func NewClient(cfg Config) (*Client, error) {
+	return NewClientContext(context.Background(), cfg)
+}
+
+// NewClientContext creates a TCP-based TS3 ServerQuery client. ctx bounds
+// dialing and the handshake; it does not affect the returned client.
+func NewClientContext(ctx context.Context, cfg Config) (*Client, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
 	if strings.TrimSpace(cfg.Host) == "" {
 		return nil, errors.New("ts3: host is required")
 	}
@@ -78,24 +165,48 @@ func NewClient(cfg Config) (*Client, error) {
 		timeout = defaultDialTimeout
 	}
 
-	conn, err := net.DialTimeout("tcp", fmt.Sprintf("%s:%d", cfg.Host, port), timeout)
+	cfg.Port = port
+	cfg.Timeout = timeout
+
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(port))
+	dial := func(ctx context.Context) (io.ReadWriteCloser, error) {
+		conn, err := dialNetwork(ctx, cfg, addr)
+		if err != nil {
+			return nil, fmt.Errorf("ts3: dial failed: %w", err)
+		}
+		return conn, nil
+	}
+
+	conn, err := dial(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("ts3: dial failed: %w", err)
+		return nil, err
 	}
+	return newClientFromConn(ctx, conn, cfg, true, dial)
+}
 
-	cfg.Port = port
-	cfg.Timeout = timeout
-	return newClientFromConn(conn, cfg, true)
+// dialNetwork opens a TCP connection with cfg.Dialer, limited by cfg.Timeout.
+func dialNetwork(ctx context.Context, cfg Config, addr string) (net.Conn, error) {
+	if cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Timeout)
+		defer cancel()
+	}
+	if cfg.Dialer != nil {
+		return cfg.Dialer(ctx, "tcp", addr)
+	}
+	var d net.Dialer
+	return d.DialContext(ctx, "tcp", addr)
 }
 
 // NewClientFromConn creates a client from an existing connection.
 //
-// It is useful for tests or custom transports.
+// It is useful for tests or custom transports. Such clients cannot redial, so
+// Config.Reconnect has no effect.
 func NewClientFromConn(conn io.ReadWriteCloser, cfg Config) (*Client, error) {
-	return newClientFromConn(conn, cfg, true)
+	return newClientFromConn(context.Background(), conn, cfg, true, nil)
 }
 
-func newClientFromConn(conn io.ReadWriteCloser, cfg Config, doHandshake bool) (*Client, error) {
+func newClientFromConn(ctx context.Context, conn io.ReadWriteCloser, cfg Config, doHandshake bool, dial dialFunc) (*Client, error) {
 	if conn == nil {
 		return nil, errors.New("ts3: nil connection")
 	}
@@ -105,44 +216,109 @@ func newClientFromConn(conn io.ReadWriteCloser, cfg Config, doHandshake bool) (*
 		maxLineSize = defaultMaxLineSize
 	}
 
-	scanner := bufio.NewScanner(conn)
-	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultDialTimeout
+	}
+
+	drainGrace := cfg.DrainGrace
+	if drainGrace <= 0 {
+		drainGrace = defaultDrainGrace
+	}
+
+	scanner := newLineScanner(conn, maxLineSize)
 
 	c := &Client{
-		conn:          conn,
-		scanner:       scanner,
-		transport:     transportRaw,
-		cmdResChan:    make(chan string, defaultCmdBufSize),
-		errorChan:     make(chan error, 1),
-		notifications: make(map[string][]func(string)),
-		quit:          make(chan struct{}),
-		logger:        &NopLogger{},
+		conn:         conn,
+		scanner:      scanner,
+		transport:    transportRaw,
+		cmdResChan:   make(chan string, defaultCmdBufSize),
+		errorChan:    make(chan error, 1),
+		quit:         make(chan struct{}),
+		logger:       &NopLogger{},
+		state:        StateReady,
+		dial:         dial,
+		onDisconnect: cfg.OnDisconnect,
+		onReconnect:  cfg.OnReconnect,
+		timeout:      timeout,
+		cmdTimeout:   cfg.CommandTimeout,
+		drainGrace:   drainGrace,
+		maxLineSize:  maxLineSize,
+		recorder:     cfg.Recorder,
+	}
+	c.events = newDispatcher(cfg.EventBuffer, cfg.EventOverflow, c.quit, c.logf)
+	if dial != nil {
+		c.reconnect = cfg.Reconnect
 	}
+	c.limiter.Store(newFloodLimiter(cfg.FloodLimit))
 
 	if doHandshake {
-		if err := c.handshake(); err != nil {
+		if err := readHandshakeContext(ctx, conn, scanner); err != nil {
 			_ = conn.Close()
 			return nil, err
 		}
 	}
 
-	go c.readLoop()
+	c.touch()
+	gen, cmdCh, errCh := c.connGen, c.cmdResChan, c.errorChan
+	c.spawn(func() { c.readLoop(gen, scanner, cmdCh, errCh) })
 
 	if cfg.KeepAlivePeriod > 0 {
-		go c.keepAliveLoop(cfg.KeepAlivePeriod)
+		c.spawn(func() { c.keepAliveLoop(cfg.KeepAlivePeriod) })
+	}
+	if cfg.ReadIdleTimeout > 0 {
+		probeTimeout := cfg.ProbeTimeout
+		if probeTimeout <= 0 {
+			probeTimeout = defaultProbeTimeout
+		}
+		c.spawn(func() { c.healthLoop(cfg.ReadIdleTimeout, probeTimeout) })
 	}
 
 	return c, nil
 }
 
-// handshake reads and validates the initial ServerQuery banner.
-func (c *Client) handshake() error {
+// newLineScanner returns a scanner that yields response rows (see scanRows),
+// so maxLineSize limits a single row rather than a whole list response.
+func newLineScanner(conn io.Reader, maxLineSize int) *bufio.Scanner {
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	scanner.Split(scanRows)
+	return scanner
+}
+
+// scanRows is a bufio.SplitFunc that splits at line ends and at the "|"
+// row separators within a line. A row that is continued on the same line
+// keeps its trailing "|".
+func scanRows(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "|\n"); i >= 0 {
+		if data[i] == '|' {
+			return i + 1, data[:i+1], nil
+		}
+		return i + 1, bytes.TrimSuffix(data[:i], []byte("\r")), nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// readHandshake reads and validates the initial ServerQuery banner.
+func readHandshake(scanner *bufio.Scanner) error {
 	lines := make([]string, 0, 2)
+	var partial string
 	for len(lines) < 2 {
-		if !c.scanner.Scan() {
+		if !scanner.Scan() {
 			return errors.New("ts3: connection closed during handshake")
 		}
-		text := strings.TrimSpace(c.scanner.Text())
+		if text, more := strings.CutSuffix(scanner.Text(), "|"); more {
+			partial += text + "|"
+			continue
+		}
+		text := strings.TrimSpace(partial + scanner.Text())
+		partial = ""
 		if text == "" {
 			continue
 		}
@@ -156,57 +332,259 @@ func (c *Client) handshake() error {
 	return nil
 }
 
+// readHandshakeContext is readHandshake that gives up when ctx is done.
+func readHandshakeContext(ctx context.Context, conn io.Closer, scanner *bufio.Scanner) error {
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	err := readHandshake(scanner)
+	if !stop() {
+		return ctx.Err()
+	}
+	return err
+}
+
 // Close closes the client connection and stops background loops.
+//
+// Close does not wait for running commands or goroutines; use Shutdown for
+// that.
 func (c *Client) Close() error {
 	var closeErr error
 	c.closeOnce.Do(func() {
 		close(c.quit)
-		if c.conn != nil {
-			closeErr = c.conn.Close()
-		}
+		c.events.closeAll()
+		closeErr = c.closeConn()
+		c.setState(StateClosed)
 	})
 	return closeErr
 }
 
+// Shutdown closes the client gracefully. It rejects new commands, waits for
+// pending Exec calls, sends "quit" on raw/SSH connections, closes the
+// connection and returns after every goroutine started by the client,
+// including notification handlers, has exited.
+//
+// If ctx ends first, the client is closed immediately and ctx.Err() is
+// returned.
+func (c *Client) Shutdown(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if c.pool != nil {
+		return c.pool.Shutdown(ctx)
+	}
+
+	c.lifeMu.Lock()
+	c.shuttingDown = true
+	c.lifeMu.Unlock()
+
+	if err := waitGroupContext(ctx, &c.execWG); err != nil {
+		_ = c.Close()
+		return err
+	}
+
+	c.sendQuit(ctx)
+	closeErr := c.Close()
+
+	if err := waitGroupContext(ctx, &c.wg); err != nil {
+		return err
+	}
+	return closeErr
+}
+
+// sendQuit ends the ServerQuery session politely. Errors are ignored because
+// the server closes the connection right after the reply.
+func (c *Client) sendQuit(ctx context.Context) {
+	if c.isWebQuery() {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	select {
+	case <-c.quit:
+		return
+	default:
+	}
+	if c.reconnecting || c.poisoned {
+		return
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	_ = c.execRaw(ctx, "quit", nil)
+}
+
+// isShuttingDown reports whether Shutdown was called.
+func (c *Client) isShuttingDown() bool {
+	c.lifeMu.RLock()
+	defer c.lifeMu.RUnlock()
+	return c.shuttingDown
+}
+
+// beginExec registers a pending command. It fails once Shutdown started.
+func (c *Client) beginExec() bool {
+	c.lifeMu.RLock()
+	defer c.lifeMu.RUnlock()
+	if c.shuttingDown {
+		return false
+	}
+	c.execWG.Add(1)
+	return true
+}
+
+// spawn starts a goroutine that Shutdown waits for.
+func (c *Client) spawn(fn func()) {
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		fn()
+	}()
+}
+
+func waitGroupContext(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // Exec sends a raw ServerQuery command and returns the data part of response.
 //
 // The returned string contains one or multiple response rows joined by "|" and
 // excludes the final "error id=..." line.
 func (c *Client) Exec(ctx context.Context, cmd string) (string, error) {
+	return collectRows(func(onRow rowFunc) error {
+		return c.exec(ctx, cmd, nil, onRow)
+	})
+}
+
+// ExecCommand sends a Command and returns the data part of the response like
+// Exec. WebQuery clients build the request URL from cmd directly.
+func (c *Client) ExecCommand(ctx context.Context, cmd *Command) (string, error) {
+	if cmd == nil || cmd.name == "" {
+		return "", errEmptyCommand
+	}
+	return collectRows(func(onRow rowFunc) error {
+		return c.exec(ctx, cmd.String(), cmd, onRow)
+	})
+}
+
+// rowFunc receives one response row. Returning false discards the remaining
+// rows of the response.
+type rowFunc func(row string) bool
+
+// collectRows runs a command and joins its rows with "|".
+func collectRows(run func(onRow rowFunc) error) (string, error) {
+	var rows []string
+	err := run(func(row string) bool {
+		rows = append(rows, row)
+		return true
+	})
+	return strings.Join(rows, "|"), err
+}
+
+// exec runs raw, or command when it is set and the transport can use it, and
+// passes the response rows to onRow. A nil onRow discards them.
+func (c *Client) exec(ctx context.Context, raw string, command *Command, onRow rowFunc) error {
 	if ctx == nil {
 		ctx = context.Background()
 	}
+	if c.pool != nil {
+		return c.pool.exec(ctx, raw, command, onRow)
+	}
+	if !c.beginExec() {
+		return errClientClosed
+	}
+	defer c.execWG.Done()
+
+	if c.cmdTimeout > 0 {
+		if _, ok := ctx.Deadline(); !ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, c.cmdTimeout)
+			defer cancel()
+		}
+	}
 
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	select {
 	case <-c.quit:
-		return "", errors.New("ts3: client closed")
+		return errClientClosed
 	default:
 	}
 
 	if c.transport == transportWebQuery {
-		return c.execWebQuery(ctx, cmd)
+		if command == nil {
+			var err error
+			if command, err = parseCommand(raw); err != nil {
+				return err
+			}
+		}
+		resp, err := c.execWebQuery(ctx, command)
+		c.recorder.execErr(command.String(), resp, err)
+		if resp != "" && onRow != nil {
+			for _, row := range strings.Split(resp, "|") {
+				if !onRow(row) {
+					break
+				}
+			}
+		}
+		return err
 	}
+	if c.reconnecting {
+		return &ConnectionError{Err: errReconnecting, Retryable: true}
+	}
+
+	return c.execLimited(ctx, raw, onRow)
+}
 
+// execRaw writes one command to the raw/SSH transport and passes the rows
+// of its reply to onRow as they arrive. The caller must hold c.mu.
+func (c *Client) execRaw(ctx context.Context, cmd string, onRow rowFunc) error {
+	if c.poisoned {
+		return c.connError(ErrProtocolSyncLost)
+	}
+	c.inFlight.Add(1)
+	defer c.inFlight.Add(-1)
 	if _, err := c.conn.Write([]byte(cmd + "\n")); err != nil {
-		return "", fmt.Errorf("ts3: write failed: %w", err)
+		return c.connError(fmt.Errorf("ts3: write failed: %w", err))
 	}
 	c.debugf("-> %s", cmd)
 
 	ctxDone := ctx.Done()
 	var ctxErr error
-	var responseLines []string
+	var graceDone <-chan time.Time
 	cmdCh := c.cmdResChan
 	errCh := c.errorChan
+	var recorded []string
+
+	// A panic in onRow leaves the rest of the reply unread.
+	finished := false
+	defer func() {
+		if !finished {
+			c.poison(cmd, cmdCh)
+		}
+	}()
 
 	for {
 		if cmdCh == nil && errCh == nil {
+			finished = true
 			if ctxErr != nil {
-				return strings.Join(responseLines, "|"), ctxErr
+				return ctxErr
 			}
-			return strings.Join(responseLines, "|"), errors.New("ts3: connection closed")
+			return c.connError(errConnClosed)
 		}
 
 		select {
@@ -215,6 +593,14 @@ func (c *Client) Exec(ctx context.Context, cmd string) (string, error) {
 			// This preserves protocol sync for subsequent commands.
 			ctxErr = ctx.Err()
 			ctxDone = nil
+			grace := time.NewTimer(c.drainGrace)
+			defer grace.Stop()
+			graceDone = grace.C
+
+		case <-graceDone:
+			c.poison(cmd, cmdCh)
+			finished = true
+			return c.connError(fmt.Errorf("%w: %w", ErrProtocolSyncLost, ctxErr))
 
 		case line, ok := <-cmdCh:
 			if !ok {
@@ -229,23 +615,34 @@ func (c *Client) Exec(ctx context.Context, cmd string) (string, error) {
 
 			if strings.HasPrefix(line, "error id=") {
 				c.debugf("<- %s", line)
+				finished = true
+				if c.recorder != nil {
+					c.recorder.exec(cmd, recorded, line)
+				}
 
 				var ts3Err Error
 				if err := NewDecoder().Decode(line, &ts3Err); err != nil {
-					return strings.Join(responseLines, "|"), fmt.Errorf("ts3: failed to decode error line: %w", err)
+					return fmt.Errorf("ts3: failed to decode error line: %w", err)
 				}
 
 				if ctxErr != nil {
-					return strings.Join(responseLines, "|"), ctxErr
+					return ctxErr
 				}
 
 				if ts3Err.ID != ErrOK {
-					return strings.Join(responseLines, "|"), &ts3Err
+					return &ts3Err
 				}
-				return strings.Join(responseLines, "|"), nil
+				return nil
 			}
 
-			responseLines = append(responseLines, line)
+			if c.recorder != nil {
+				recorded = append(recorded, line)
+			}
+
+			// Rows after a cancellation or a stopped consumer are drained.
+			if onRow != nil && ctxErr == nil && !onRow(line) {
+				onRow = nil
+			}
 
 		case err, ok := <-errCh:
 			if !ok {
@@ -253,62 +650,117 @@ func (c *Client) Exec(ctx context.Context, cmd string) (string, error) {
 				continue
 			}
 			if err != nil {
-				return strings.Join(responseLines, "|"), fmt.Errorf("ts3: connection error: %w", err)
+				finished = true
+				return c.connError(fmt.Errorf("ts3: connection error: %w", err))
 			}
 
 		case <-c.quit:
+			finished = true
 			if ctxErr != nil {
-				return strings.Join(responseLines, "|"), ctxErr
+				return ctxErr
 			}
-			return strings.Join(responseLines, "|"), errors.New("ts3: client closed")
+			return errClientClosed
 		}
 	}
 }
 
-// readLoop continuously reads lines from the connection.
-func (c *Client) readLoop() {
+// readLoop continuously reads response rows from one connection.
+//
+// gen identifies the connection, so that a loop belonging to a replaced
+// connection does not trigger another reconnect.
+func (c *Client) readLoop(gen uint64, scanner *bufio.Scanner, cmdResChan chan string, errorChan chan error) {
+	var cause error
 	defer func() {
 		if r := recover(); r != nil {
 			c.logf("panic in readLoop: %v", r)
-			c.sendConnErr(fmt.Errorf("ts3: panic in readLoop: %v", r))
+			cause = fmt.Errorf("ts3: panic in readLoop: %v", r)
+			sendConnErr(errorChan, cause)
 		}
-		close(c.cmdResChan)
-		close(c.errorChan)
+		close(cmdResChan)
+		close(errorChan)
+		c.connLost(gen, cause)
 	}()
 
-	for c.scanner.Scan() {
-		text := strings.TrimSpace(c.scanner.Text())
-		if text == "" {
+	// Rows of one notification line are joined again before dispatching.
+	var notifyRows []string
+	midLine := false
+	for scanner.Scan() {
+		c.touch()
+		text, more := strings.CutSuffix(scanner.Text(), "|")
+		text = strings.TrimSpace(text)
+		lineStart := !midLine
+		midLine = more
+
+		if notifyRows != nil || (lineStart && strings.HasPrefix(text, "notify")) {
+			notifyRows = append(notifyRows, text)
+			if !more {
+				line := strings.Join(notifyRows, "|")
+				notifyRows = nil
+				c.dispatchNotify(line)
+			}
 			continue
 		}
-
-		if strings.HasPrefix(text, "notify") {
-			go c.dispatchNotify(text)
+		if text == "" {
 			continue
 		}
 
 		select {
-		case c.cmdResChan <- text:
+		case cmdResChan <- text:
 		case <-c.quit:
 			return
 		}
 	}
 
-	if err := c.scanner.Err(); err != nil {
-		c.sendConnErr(err)
+	if err := scanner.Err(); err != nil {
+		cause = err
+		sendConnErr(errorChan, err)
 	}
 }
 
-func (c *Client) sendConnErr(err error) {
+func sendConnErr(errorChan chan error, err error) {
 	if err == nil {
 		return
 	}
 	select {
-	case c.errorChan <- err:
+	case errorChan <- err:
 	default:
 	}
 }
 
+// poison closes a connection whose replies can no longer be matched to
+// commands. readLoop then reports the loss, which triggers a reconnect when
+// configured. The caller must hold c.mu.
+func (c *Client) poison(cmd string, cmdCh chan string) {
+	c.logf("ts3: no reply to %s within %s after cancellation, closing connection", commandName(cmd), c.drainGrace)
+	c.poisoned = true
+	_ = c.closeConn()
+
+	// Discard late replies so readLoop is never blocked on a full buffer.
+	if cmdCh != nil {
+		c.spawn(func() {
+			for range cmdCh {
+			}
+		})
+	}
+}
+
+// closeConn closes the current transport without closing the client.
+func (c *Client) closeConn() error {
+	c.connMu.Lock()
+	conn := c.conn
+	c.connMu.Unlock()
+	if conn == nil {
+		return nil
+	}
+	return conn.Close()
+}
+
+// connError wraps a transport failure. The error is retryable when the
+// client will try to restore the connection.
+func (c *Client) connError(err error) error {
+	return &ConnectionError{Err: err, Retryable: c.reconnect != nil}
+}
+
 // keepAliveLoop periodically executes "whoami" to keep long-lived sessions alive.
 func (c *Client) keepAliveLoop(period time.Duration) {
 	ticker := time.NewTicker(period)
@@ -1,8 +1,14 @@
 package ts3
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
 
 	"golang.org/x/crypto/ssh"
 )
@@ -13,7 +19,11 @@ type sshConnWrapper struct {
 	stdin   io.WriteCloser
 	stdout  io.Reader
 	session *ssh.Session
-	client  *ssh.Client
+
+	// release frees the SSH connection carrying the session.
+	release   func() error
+	closeOnce sync.Once
+	closeErr  error
 }
 
 func (w *sshConnWrapper) Read(p []byte) (n int, err error) {
@@ -25,76 +35,163 @@ func (w *sshConnWrapper) Write(p []byte) (n int, err error) {
 }
 
 func (w *sshConnWrapper) Close() error {
-	_ = w.stdin.Close()
-	_ = w.session.Close()
-	return w.client.Close()
+	w.closeOnce.Do(func() {
+		_ = w.stdin.Close()
+		_ = w.session.Close()
+		w.closeErr = w.release()
+	})
+	return w.closeErr
 }
 
 // NewSSHClient creates a TS3 ServerQuery client over SSH.
 //
 // TS3 SSH ServerQuery usually listens on port 10022.
+//
+// The server host key is not verified. Use NewSSHClientWithSSHConfig in
+// production.
 func NewSSHClient(host string, port int, user, password string) (*Client, error) {
 	return NewSSHClientWithConfig(host, port, user, password, Config{})
 }
 
 // NewSSHClientWithConfig creates an SSH client with custom runtime options.
+//
+// The server host key is not verified. Use NewSSHClientWithSSHConfig in
+// production.
 func NewSSHClientWithConfig(host string, port int, user, password string, cfg Config) (*Client, error) {
-	if port == 0 {
-		port = defaultQuerySSHPort
+	cfg.Host = host
+	cfg.Port = port
+	return NewSSHClientWithSSHConfig(cfg, SSHConfig{
+		User:                  user,
+		Password:              password,
+		InsecureIgnoreHostKey: true,
+	})
+}
+
+// NewSSHClientWithSSHConfig creates an SSH client with host key verification
+// and the authentication methods configured in sshCfg.
+//
+// cfg.Host is required; cfg.Port defaults to 10022.
+func NewSSHClientWithSSHConfig(cfg Config, sshCfg SSHConfig) (*Client, error) {
+	return NewSSHClientContext(context.Background(), cfg, sshCfg)
+}
+
+// NewSSHClientContext is NewSSHClientWithSSHConfig with a context that bounds
+// dialing, the SSH handshake and the query banner.
+func NewSSHClientContext(ctx context.Context, cfg Config, sshCfg SSHConfig) (*Client, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if strings.TrimSpace(cfg.Host) == "" {
+		return nil, errors.New("ts3: host is required")
+	}
+	if cfg.Port == 0 {
+		cfg.Port = defaultQuerySSHPort
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultDialTimeout
+	}
+
+	// Fail early on configuration errors instead of on every redial.
+	_, cleanup, err := sshCfg.clientConfig(cfg.Host, cfg.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	cleanup()
+
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	dial := func(ctx context.Context) (io.ReadWriteCloser, error) {
+		clientCfg, cleanup, err := sshCfg.clientConfig(cfg.Host, cfg.Timeout)
+		if err != nil {
+			return nil, err
+		}
+		defer cleanup()
+
+		wrapper, err := dialSSH(ctx, cfg, addr, clientCfg)
+		if err != nil {
+			return nil, err
+		}
+		return wrapper, nil
 	}
 
-	timeout := cfg.Timeout
-	if timeout <= 0 {
-		timeout = defaultDialTimeout
+	wrapper, err := dial(ctx)
+	if err != nil {
+		return nil, err
 	}
+	return newClientFromConn(ctx, wrapper, cfg, true, dial)
+}
 
-	sshCfg := &ssh.ClientConfig{
-		User:            user,
-		Auth:            []ssh.AuthMethod{ssh.Password(password)},
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
-		Timeout:         timeout,
+// dialSSH opens an SSH connection with one interactive shell session.
+func dialSSH(ctx context.Context, cfg Config, addr string, sshCfg *ssh.ClientConfig) (*sshConnWrapper, error) {
+	sshClient, err := dialSSHClient(ctx, cfg, addr, sshCfg)
+	if err != nil {
+		return nil, err
 	}
 
-	addr := fmt.Sprintf("%s:%d", host, port)
-	sshClient, err := ssh.Dial("tcp", addr, sshCfg)
+	wrapper, err := openSSHShell(sshClient, sshClient.Close)
 	if err != nil {
+		_ = sshClient.Close()
+		return nil, err
+	}
+	return wrapper, nil
+}
+
+// dialSSHClient connects with cfg.Dialer and performs the SSH handshake.
+// cfg.Timeout bounds both steps.
+func dialSSHClient(ctx context.Context, cfg Config, addr string, sshCfg *ssh.ClientConfig) (*ssh.Client, error) {
+	if cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Timeout)
+		defer cancel()
+	}
+
+	conn, err := dialNetwork(ctx, cfg, addr)
+	if err != nil {
+		return nil, fmt.Errorf("ts3: ssh dial failed: %w", err)
+	}
+
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	clientConn, chans, reqs, err := ssh.NewClientConn(conn, addr, sshCfg)
+	if !stop() {
+		if err == nil {
+			_ = clientConn.Close()
+		}
+		return nil, fmt.Errorf("ts3: ssh dial failed: %w", ctx.Err())
+	}
+	if err != nil {
+		_ = conn.Close()
 		return nil, fmt.Errorf("ts3: ssh dial failed: %w", err)
 	}
+	return ssh.NewClient(clientConn, chans, reqs), nil
+}
 
+// openSSHShell starts an interactive shell session on an existing SSH
+// connection. release is called when the session is closed.
+func openSSHShell(sshClient *ssh.Client, release func() error) (*sshConnWrapper, error) {
 	session, err := sshClient.NewSession()
 	if err != nil {
-		_ = sshClient.Close()
 		return nil, fmt.Errorf("ts3: ssh new session failed: %w", err)
 	}
 
 	stdin, err := session.StdinPipe()
 	if err != nil {
 		_ = session.Close()
-		_ = sshClient.Close()
 		return nil, fmt.Errorf("ts3: ssh stdin pipe failed: %w", err)
 	}
 	stdout, err := session.StdoutPipe()
 	if err != nil {
 		_ = session.Close()
-		_ = sshClient.Close()
 		return nil, fmt.Errorf("ts3: ssh stdout pipe failed: %w", err)
 	}
 
 	if err := session.Shell(); err != nil {
 		_ = session.Close()
-		_ = sshClient.Close()
 		return nil, fmt.Errorf("ts3: ssh shell failed: %w", err)
 	}
 
-	wrapper := &sshConnWrapper{
+	return &sshConnWrapper{
 		stdin:   stdin,
 		stdout:  stdout,
 		session: session,
-		client:  sshClient,
-	}
-
-	cfg.Host = host
-	cfg.Port = port
-	cfg.Timeout = timeout
-	return newClientFromConn(wrapper, cfg, true)
+		release: release,
+	}, nil
 }
@@ -0,0 +1,306 @@
+package ts3
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// mockSSHServer is an SSH ServerQuery endpoint where every shell channel is
+// an independent query session.
+type mockSSHServer struct {
+	ln         net.Listener
+	hostKey    ssh.Signer
+	userKey    ssh.Signer
+	handler    func(cmd string) []string
+	handshakes atomic.Int32
+	sessions   atomic.Int32
+}
+
+func newMockSSHServer(t *testing.T, handler func(cmd string) []string) *mockSSHServer {
+	t.Helper()
+
+	s := &mockSSHServer{
+		hostKey: newTestSigner(t),
+		userKey: newTestSigner(t),
+		handler: handler,
+	}
+
+	serverCfg := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if conn.User() == "serveradmin" && string(password) == "secret" {
+				return nil, nil
+			}
+			return nil, errors.New("invalid password")
+		},
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if bytes.Equal(key.Marshal(), s.userKey.PublicKey().Marshal()) {
+				return nil, nil
+			}
+			return nil, errors.New("unknown key")
+		},
+	}
+	serverCfg.AddHostKey(s.hostKey)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	s.ln = ln
+	t.Cleanup(func() { _ = ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serveConn(conn, serverCfg)
+		}
+	}()
+	return s
+}
+
+func (s *mockSSHServer) serveConn(conn net.Conn, cfg *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, cfg)
+	if err != nil {
+		_ = conn.Close()
+		return
+	}
+	s.handshakes.Add(1)
+	go ssh.DiscardRequests(reqs)
+
+	for newCh := range chans {
+		if newCh.ChannelType() != "session" {
+			_ = newCh.Reject(ssh.UnknownChannelType, "unsupported")
+			continue
+		}
+		ch, chReqs, err := newCh.Accept()
+		if err != nil {
+			continue
+		}
+		s.sessions.Add(1)
+		go func() {
+			for req := range chReqs {
+				_ = req.Reply(req.Type == "shell", nil)
+				if req.Type == "shell" {
+					go s.serveQuery(ch)
+				}
+			}
+		}()
+	}
+}
+
+func (s *mockSSHServer) serveQuery(ch ssh.Channel) {
+	defer ch.Close()
+
+	writer := bufio.NewWriter(ch)
+	_, _ = writer.WriteString("TS3\nWelcome to TeamSpeak 3 ServerQuery\n")
+	_ = writer.Flush()
+
+	scanner := bufio.NewScanner(ch)
+	for scanner.Scan() {
+		for _, line := range s.handler(strings.TrimSpace(scanner.Text())) {
+			_, _ = writer.WriteString(line + "\n")
+		}
+		_ = writer.Flush()
+	}
+}
+
+func (s *mockSSHServer) config(t *testing.T) Config {
+	t.Helper()
+	host, portStr, err := net.SplitHostPort(s.ln.Addr().String())
+	if err != nil {
+		t.Fatalf("split host port failed: %v", err)
+	}
+	port, _ := strconv.Atoi(portStr)
+	return Config{Host: host, Port: port, Timeout: 2 * time.Second}
+}
+
+func newTestSigner(t *testing.T) ssh.Signer {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key failed: %v", err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatalf("create signer failed: %v", err)
+	}
+	return signer
+}
+
+func okQueryHandler(cmd string) []string {
+	if cmd == "whoami" {
+		return []string{"virtualserver_id=1 client_id=7", "error id=0 msg=ok"}
+	}
+	return []string{"error id=0 msg=ok"}
+}
+
+func TestSSHClientPinnedFingerprint(t *testing.T) {
+	srv := newMockSSHServer(t, okQueryHandler)
+
+	client, err := NewSSHClientWithSSHConfig(srv.config(t), SSHConfig{
+		User:               "serveradmin",
+		Password:           "secret",
+		HostKeyFingerprint: ssh.FingerprintSHA256(srv.hostKey.PublicKey()),
+	})
+	if err != nil {
+		t.Fatalf("NewSSHClientWithSSHConfig failed: %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	me, err := client.WhoAmI(ctx)
+	if err != nil {
+		t.Fatalf("WhoAmI failed: %v", err)
+	}
+	if me.ClientID != 7 {
+		t.Fatalf("unexpected whoami: %+v", me)
+	}
+}
+
+func TestSSHClientHostKeyMismatch(t *testing.T) {
+	srv := newMockSSHServer(t, okQueryHandler)
+	other := newTestSigner(t)
+
+	_, err := NewSSHClientWithSSHConfig(srv.config(t), SSHConfig{
+		User:               "serveradmin",
+		Password:           "secret",
+		HostKeyFingerprint: ssh.FingerprintSHA256(other.PublicKey()),
+	})
+	var hostKeyErr *HostKeyError
+	if !errors.As(err, &hostKeyErr) {
+		t.Fatalf("expected HostKeyError, got: %T (%v)", err, err)
+	}
+	if hostKeyErr.Fingerprint != ssh.FingerprintSHA256(srv.hostKey.PublicKey()) {
+		t.Fatalf("unexpected presented fingerprint: %s", hostKeyErr.Fingerprint)
+	}
+
+	// Authentication failures must not look like host key errors.
+	_, err = NewSSHClientWithSSHConfig(srv.config(t), SSHConfig{
+		User:                  "serveradmin",
+		Password:              "wrong",
+		InsecureIgnoreHostKey: true,
+	})
+	if err == nil || errors.As(err, &hostKeyErr) {
+		t.Fatalf("expected plain auth error, got: %v", err)
+	}
+}
+
+func TestSSHClientKnownHostsAndPublicKey(t *testing.T) {
+	srv := newMockSSHServer(t, okQueryHandler)
+	cfg := srv.config(t)
+
+	knownHostsFile := filepath.Join(t.TempDir(), "known_hosts")
+	line := knownhosts.Line([]string{knownhosts.Normalize(srv.ln.Addr().String())}, srv.hostKey.PublicKey())
+	if err := os.WriteFile(knownHostsFile, []byte(line+"\n"), 0o600); err != nil {
+		t.Fatalf("write known_hosts failed: %v", err)
+	}
+
+	client, err := NewSSHClientWithSSHConfig(cfg, SSHConfig{
+		User:            "serveradmin",
+		Signers:         []ssh.Signer{srv.userKey},
+		KnownHostsFiles: []string{knownHostsFile},
+	})
+	if err != nil {
+		t.Fatalf("NewSSHClientWithSSHConfig failed: %v", err)
+	}
+	_ = client.Close()
+}
+
+func TestSSHConfigRequiresHostKeyVerification(t *testing.T) {
+	_, err := NewSSHClientWithSSHConfig(Config{Host: "127.0.0.1"}, SSHConfig{
+		User:     "serveradmin",
+		Password: "secret",
+	})
+	if !errors.Is(err, errSSHHostKeyNotConfigured) {
+		t.Fatalf("expected host key configuration error, got: %v", err)
+	}
+}
+
+func TestSSHConnMultiplexesSessions(t *testing.T) {
+	srv := newMockSSHServer(t, okQueryHandler)
+
+	conn, err := DialSSH(srv.config(t), SSHConfig{
+		User:               "serveradmin",
+		Password:           "secret",
+		HostKeyFingerprint: ssh.FingerprintSHA256(srv.hostKey.PublicKey()),
+	})
+	if err != nil {
+		t.Fatalf("DialSSH failed: %v", err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	clients := make([]*Client, 3)
+	for i := range clients {
+		client, err := conn.NewClient()
+		if err != nil {
+			t.Fatalf("NewClient #%d failed: %v", i, err)
+		}
+		clients[i] = client
+	}
+
+	errCh := make(chan error, len(clients))
+	for _, client := range clients {
+		go func(c *Client) {
+			_, err := c.WhoAmI(ctx)
+			errCh <- err
+		}(client)
+	}
+	for range clients {
+		if err := <-errCh; err != nil {
+			t.Fatalf("WhoAmI failed: %v", err)
+		}
+	}
+
+	if got := srv.handshakes.Load(); got != 1 {
+		t.Fatalf("expected one ssh handshake, got %d", got)
+	}
+	if got := srv.sessions.Load(); got != 3 {
+		t.Fatalf("expected three ssh sessions, got %d", got)
+	}
+
+	_ = clients[0].Close()
+	_ = clients[1].Close()
+	if _, err := clients[2].WhoAmI(ctx); err != nil {
+		t.Fatalf("remaining session failed after others closed: %v", err)
+	}
+	if got := conn.Sessions(); got != 1 {
+		t.Fatalf("expected one open session, got %d", got)
+	}
+
+	_ = clients[2].Close()
+	if got := conn.Sessions(); got != 0 {
+		t.Fatalf("expected no open sessions, got %d", got)
+	}
+
+	// The next session dials a new connection.
+	client, err := conn.NewClient()
+	if err != nil {
+		t.Fatalf("NewClient after last close failed: %v", err)
+	}
+	defer client.Close()
+	if got := srv.handshakes.Load(); got != 2 {
+		t.Fatalf("expected a second ssh handshake, got %d", got)
+	}
+}
@@ -146,3 +146,103 @@ func TestOnTextMessageDispatch(t *testing.T) {
 		t.Fatalf("text notification handler was not called")
 	}
 }
+
+func TestExecDrainGraceClosesDesyncedConnection(t *testing.T) {
+	conn := newMockServerConn(t, func(cmd string) []string {
+		if cmd == "hang" {
+			return nil
+		}
+		return []string{"error id=0 msg=ok"}
+	})
+
+	client, err := NewClientFromConn(conn, Config{
+		CommandTimeout: 50 * time.Millisecond,
+		DrainGrace:     100 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewClientFromConn failed: %v", err)
+	}
+	defer client.Close()
+
+	start := time.Now()
+	_, err = client.Exec(context.Background(), "hang")
+	if !errors.Is(err, ErrProtocolSyncLost) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected protocol sync error, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Exec returned too late: %v", elapsed)
+	}
+
+	if _, err := client.Exec(context.Background(), "whoami"); !errors.Is(err, ErrProtocolSyncLost) {
+		t.Fatalf("expected poisoned connection, got: %v", err)
+	}
+}
+
+func TestShutdownWaitsForExecAndHandlers(t *testing.T) {
+	quitReceived := make(chan struct{}, 1)
+	conn := newMockServerConn(t, func(cmd string) []string {
+		switch cmd {
+		case "slow":
+			time.Sleep(100 * time.Millisecond)
+			return []string{"notifytextmessage invokername=Alice msg=hello", "error id=0 msg=ok"}
+		case "quit":
+			quitReceived <- struct{}{}
+		}
+		return []string{"error id=0 msg=ok"}
+	})
+
+	client, err := NewClientFromConn(conn, Config{KeepAlivePeriod: time.Hour})
+	if err != nil {
+		t.Fatalf("NewClientFromConn failed: %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	handlerStarted := make(chan struct{})
+	releaseHandler := make(chan struct{})
+	if err := client.OnTextMessage(ctx, func(string) {
+		close(handlerStarted)
+		<-releaseHandler
+	}); err != nil {
+		t.Fatalf("OnTextMessage failed: %v", err)
+	}
+
+	execErr := make(chan error, 1)
+	go func() {
+		_, err := client.Exec(ctx, "slow")
+		execErr <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	shutdownErr := make(chan error, 1)
+	go func() { shutdownErr <- client.Shutdown(ctx) }()
+
+	if err := <-execErr; err != nil {
+		t.Fatalf("pending Exec failed: %v", err)
+	}
+	<-handlerStarted
+	select {
+	case <-quitReceived:
+	case <-time.After(time.Second):
+		t.Fatalf("quit was not sent")
+	}
+
+	select {
+	case err := <-shutdownErr:
+		t.Fatalf("Shutdown returned before handler finished: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(releaseHandler)
+
+	if err := <-shutdownErr; err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	if _, err := client.Exec(ctx, "whoami"); !errors.Is(err, errClientClosed) {
+		t.Fatalf("expected closed client, got: %v", err)
+	}
+	if client.State() != StateClosed {
+		t.Fatalf("unexpected state after shutdown: %v", client.State())
+	}
+}
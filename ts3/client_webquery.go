@@ -28,6 +28,17 @@ type WebQueryConfig struct {
 	KeepAlivePeriod time.Duration
 	VirtualServerID int
 	HTTPClient      *http.Client
+
+	// Poll enables synthesized client, channel and server events. Nil
+	// disables polling, and the event APIs return an error.
+	Poll *PollPolicy
+	// EventBuffer and EventOverflow configure event subscriptions like the
+	// fields of Config.
+	EventBuffer   int
+	EventOverflow OverflowPolicy
+
+	// Recorder, when set, records polled events and command exchanges.
+	Recorder *Recorder
 }
 
 type webQueryRuntime struct {
@@ -35,12 +46,7 @@ type webQueryRuntime struct {
 	basePath   string
 	apiKey     string
 	httpClient *http.Client
-}
-
-type webQueryArg struct {
-	key      string
-	value    string
-	hasValue bool
+	poller     *webQueryPoller
 }
 
 var webQueryGlobalCommands = map[string]struct{}{
@@ -107,15 +113,22 @@ func NewWebQueryClient(cfg WebQueryConfig) (*Client, error) {
 			apiKey:     cfg.APIKey,
 			httpClient: httpClient,
 		},
-		transport:     transportWebQuery,
-		selectedSID:   cfg.VirtualServerID,
-		notifications: make(map[string][]func(string)),
-		quit:          make(chan struct{}),
-		logger:        &NopLogger{},
+		transport:   transportWebQuery,
+		selectedSID: cfg.VirtualServerID,
+		quit:        make(chan struct{}),
+		logger:      &NopLogger{},
+		state:       StateReady,
+		recorder:    cfg.Recorder,
 	}
+	c.events = newDispatcher(cfg.EventBuffer, cfg.EventOverflow, c.quit, c.logf)
 
 	if cfg.KeepAlivePeriod > 0 {
-		go c.keepAliveLoop(cfg.KeepAlivePeriod)
+		c.spawn(func() { c.keepAliveLoop(cfg.KeepAlivePeriod) })
+	}
+	if cfg.Poll != nil {
+		poller := newWebQueryPoller(*cfg.Poll)
+		c.web.poller = poller
+		c.spawn(func() { c.pollLoop(poller) })
 	}
 
 	return c, nil
@@ -130,19 +143,14 @@ func normalizeWebQueryBasePath(path string) string {
 	return "/" + path
 }
 
-func (c *Client) execWebQuery(ctx context.Context, rawCmd string) (string, error) {
-	cmd, args, err := parseWebQueryCommand(rawCmd)
-	if err != nil {
-		return "", err
-	}
-
+func (c *Client) execWebQuery(ctx context.Context, cmd *Command) (string, error) {
 	path := c.web.basePath
-	if sid := c.selectedSID; sid > 0 && !isWebQueryGlobalCommand(cmd) {
+	if sid := c.selectedSID; sid > 0 && !isWebQueryGlobalCommand(cmd.name) {
 		path += "/" + strconv.Itoa(sid)
 	}
-	path += "/" + cmd
+	path += "/" + cmd.name
 
-	if query := buildWebQueryQuery(args); query != "" {
+	if query := buildWebQueryQuery(cmd); query != "" {
 		path += "?" + query
 	}
 	endpoint := c.web.baseURL + path
@@ -187,41 +195,10 @@ func (c *Client) execWebQuery(ctx context.Context, rawCmd string) (string, error
 	return response, nil
 }
 
-func parseWebQueryCommand(raw string) (string, []webQueryArg, error) {
-	fields := strings.Fields(strings.TrimSpace(raw))
-	if len(fields) == 0 {
-		return "", nil, errors.New("ts3: empty command")
-	}
-
-	cmd := fields[0]
-	args := make([]webQueryArg, 0, len(fields)-1)
-	for _, field := range fields[1:] {
-		chunks := strings.Split(field, "|")
-		for _, chunk := range chunks {
-			if chunk == "" {
-				continue
-			}
-			kv := strings.SplitN(chunk, "=", 2)
-			if len(kv) == 2 {
-				args = append(args, webQueryArg{
-					key:      kv[0],
-					value:    kv[1],
-					hasValue: true,
-				})
-				continue
-			}
-
-			args = append(args, webQueryArg{
-				key:      chunk,
-				hasValue: false,
-			})
-		}
-	}
-
-	return cmd, args, nil
-}
-
-func buildWebQueryQuery(args []webQueryArg) string {
+// buildWebQueryQuery encodes the arguments of cmd as URL query. Flags are
+// sent as keys without value.
+func buildWebQueryQuery(cmd *Command) string {
+	args := cmd.args()
 	if len(args) == 0 {
 		return ""
 	}
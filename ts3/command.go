@@ -0,0 +1,237 @@
+package ts3
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Option flags of the list commands. Using the constants instead of string
+// literals turns typos into compile errors.
+const (
+	OptUID             = "-uid"
+	OptAway            = "-away"
+	OptVoice           = "-voice"
+	OptTimes           = "-times"
+	OptGroups          = "-groups"
+	OptInfo            = "-info"
+	OptIcon            = "-icon"
+	OptCountry         = "-country"
+	OptIP              = "-ip"
+	OptBadges          = "-badges"
+	OptTopic           = "-topic"
+	OptFlags           = "-flags"
+	OptLimits          = "-limits"
+	OptSecondsEmpty    = "-secondsempty"
+	OptCount           = "-count"
+	OptNames           = "-names"
+	OptShort           = "-short"
+	OptAll             = "-all"
+	OptOnlyOffline     = "-onlyoffline"
+	OptContinueOnError = "-continueonerror"
+)
+
+var errEmptyCommand = errors.New("ts3: empty command")
+
+type commandParam struct {
+	key      string
+	value    string
+	hasValue bool
+}
+
+type commandGroup struct {
+	key    string
+	values []string
+}
+
+// Command is a ServerQuery command built from typed parts. Values are stored
+// unescaped; each transport serializes them in its own syntax.
+//
+//	cmd := ts3.NewCommand("clientlist").Flag(ts3.OptUID, ts3.OptGroups)
+//	cmd := ts3.NewCommand("clientmove").Group("clid", 5, 6).Param("cid", 2)
+type Command struct {
+	name   string
+	params []commandParam
+	flags  []string
+	groups []commandGroup
+}
+
+// NewCommand creates a command with the given name, e.g. "clientlist".
+func NewCommand(name string) *Command {
+	return &Command{name: strings.TrimSpace(name)}
+}
+
+// Name returns the command name.
+func (c *Command) Name() string {
+	return c.name
+}
+
+// Param adds key=value. value is formatted like the Encoder does: bools as
+// 1/0, numbers in decimal, time.Duration in seconds and time.Time as Unix
+// seconds.
+func (c *Command) Param(key string, value interface{}) *Command {
+	c.params = append(c.params, commandParam{key: key, value: formatCommandValue(value), hasValue: true})
+	return c
+}
+
+// Flag adds option flags such as "-uid". The leading "-" is optional.
+func (c *Command) Flag(names ...string) *Command {
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !strings.HasPrefix(name, "-") {
+			name = "-" + name
+		}
+		c.flags = append(c.flags, name)
+	}
+	return c
+}
+
+// Group adds a repeated parameter, serialized as key=v1|key=v2. Groups with
+// different keys are merged row by row, e.g. Group("permid", 1, 2) and
+// Group("permvalue", 75, 50) become "permid=1 permvalue=75|permid=2 permvalue=50".
+func (c *Command) Group(key string, values ...interface{}) *Command {
+	g := commandGroup{key: key, values: make([]string, 0, len(values))}
+	for _, v := range values {
+		g.values = append(g.values, formatCommandValue(v))
+	}
+	c.groups = append(c.groups, g)
+	return c
+}
+
+// String returns the command in ServerQuery syntax with escaped values.
+func (c *Command) String() string {
+	var b strings.Builder
+	b.WriteString(c.name)
+	for _, p := range c.params {
+		b.WriteByte(' ')
+		b.WriteString(p.key)
+		if p.hasValue {
+			b.WriteByte('=')
+			b.WriteString(Escape(p.value))
+		}
+	}
+	for _, flag := range c.flags {
+		b.WriteByte(' ')
+		b.WriteString(flag)
+	}
+
+	rows := 0
+	for _, g := range c.groups {
+		rows = max(rows, len(g.values))
+	}
+	for row := 0; row < rows; row++ {
+		if row == 0 {
+			b.WriteByte(' ')
+		} else {
+			b.WriteByte('|')
+		}
+		first := true
+		for _, g := range c.groups {
+			if row >= len(g.values) {
+				continue
+			}
+			if !first {
+				b.WriteByte(' ')
+			}
+			first = false
+			b.WriteString(g.key)
+			b.WriteByte('=')
+			b.WriteString(Escape(g.values[row]))
+		}
+	}
+	return b.String()
+}
+
+// args returns all parameters, group values and flags in order, unescaped.
+func (c *Command) args() []commandParam {
+	out := make([]commandParam, 0, len(c.params)+len(c.flags))
+	out = append(out, c.params...)
+	for _, g := range c.groups {
+		for _, v := range g.values {
+			out = append(out, commandParam{key: g.key, value: v, hasValue: true})
+		}
+	}
+	for _, flag := range c.flags {
+		out = append(out, commandParam{key: flag})
+	}
+	return out
+}
+
+// addRaw adds already escaped ServerQuery tokens such as "-uid",
+// "start=0" or "cid=1|cid=2". Keys that repeat after a "|" become groups.
+func (c *Command) addRaw(raw string) *Command {
+	rows := strings.Split(raw, "|")
+
+	repeated := make(map[string]bool)
+	for _, row := range rows[1:] {
+		for _, field := range strings.Fields(row) {
+			key, _, _ := strings.Cut(field, "=")
+			repeated[key] = true
+		}
+	}
+
+	for _, row := range rows {
+		for _, field := range strings.Fields(row) {
+			key, value, ok := strings.Cut(field, "=")
+			switch {
+			case !ok && strings.HasPrefix(key, "-"):
+				c.flags = append(c.flags, key)
+			case !ok:
+				c.params = append(c.params, commandParam{key: key})
+			case repeated[key]:
+				c.appendGroupValue(key, Unescape(value))
+			default:
+				c.params = append(c.params, commandParam{key: key, value: Unescape(value), hasValue: true})
+			}
+		}
+	}
+	return c
+}
+
+func (c *Command) appendGroupValue(key, value string) {
+	for i := range c.groups {
+		if c.groups[i].key == key {
+			c.groups[i].values = append(c.groups[i].values, value)
+			return
+		}
+	}
+	c.groups = append(c.groups, commandGroup{key: key, values: []string{value}})
+}
+
+// parseCommand converts a raw ServerQuery command line into a Command.
+func parseCommand(raw string) (*Command, error) {
+	name, rest, _ := strings.Cut(strings.TrimSpace(raw), " ")
+	if name == "" {
+		return nil, errEmptyCommand
+	}
+	return NewCommand(name).addRaw(rest), nil
+}
+
+func formatCommandValue(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case time.Time, time.Duration:
+		s, _ := encodeValue(reflect.ValueOf(val), nil)
+		return s
+	case fmt.Stringer:
+		return val.String()
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		s, _ := encodeValue(rv, nil)
+		return s
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,92 @@
+package ts3
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCommandString(t *testing.T) {
+	cmd := NewCommand("servergroupaddperm").
+		Param("sgid", 6).
+		Param("permnegated", false).
+		Group("permsid", "b_client_kick", "i_client_talk_power").
+		Group("permvalue", 1, 75).
+		Flag("continueonerror")
+
+	want := "servergroupaddperm sgid=6 permnegated=0 -continueonerror permsid=b_client_kick permvalue=1|permsid=i_client_talk_power permvalue=75"
+	if got := cmd.String(); got != want {
+		t.Fatalf("unexpected command:\ngot:  %q\nwant: %q", got, want)
+	}
+
+	msg := NewCommand("sendtextmessage").Param("msg", "hello world|bye")
+	if got := msg.String(); got != "sendtextmessage msg=hello\\sworld\\pbye" {
+		t.Fatalf("unexpected escaping: %q", got)
+	}
+}
+
+func TestCommandParamFormatsLikeEncoder(t *testing.T) {
+	cmd := NewCommand("banadd").
+		Param("time", time.Hour).
+		Param("until", time.Unix(1700000000, 0)).
+		Param("i8", int8(-3)).
+		Param("u16", uint16(7)).
+		Param("i32", int32(42)).
+		Param("u64", uint64(1)<<40).
+		Param("ratio", 0.5)
+
+	want := "banadd time=3600 until=1700000000 i8=-3 u16=7 i32=42 u64=1099511627776 ratio=0.5"
+	if got := cmd.String(); got != want {
+		t.Fatalf("unexpected command:\ngot:  %q\nwant: %q", got, want)
+	}
+}
+
+func TestParseCommandRoundTrip(t *testing.T) {
+	raw := "clientmove cid=2 cpw=a\\sb clid=5|clid=6 -continueonerror"
+	cmd, err := parseCommand(raw)
+	if err != nil {
+		t.Fatalf("parseCommand failed: %v", err)
+	}
+	if got := cmd.String(); got != "clientmove cid=2 cpw=a\\sb -continueonerror clid=5|clid=6" {
+		t.Fatalf("unexpected command: %q", got)
+	}
+
+	want := []commandParam{
+		{key: "cid", value: "2", hasValue: true},
+		{key: "cpw", value: "a b", hasValue: true},
+		{key: "clid", value: "5", hasValue: true},
+		{key: "clid", value: "6", hasValue: true},
+		{key: "-continueonerror"},
+	}
+	if got := cmd.args(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected args:\ngot:  %+v\nwant: %+v", got, want)
+	}
+}
+
+func TestWebQueryExecCommandBuildsURL(t *testing.T) {
+	client, srv := newWebQueryTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/1/sendtextmessage" {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+		q := r.URL.Query()
+		if got := q.Get("msg"); got != "hello world|bye" {
+			t.Fatalf("unexpected msg: %q", got)
+		}
+		if got := q["target"]; !reflect.DeepEqual(got, []string{"3", "4"}) {
+			t.Fatalf("unexpected targets: %v", got)
+		}
+		writeWebQueryOK(t, w, nil)
+	}, 1)
+	defer srv.Close()
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	cmd := NewCommand("sendtextmessage").Param("targetmode", TextTargetClient).Group("target", 3, 4).Param("msg", "hello world|bye")
+	if _, err := client.ExecCommand(ctx, cmd); err != nil {
+		t.Fatalf("ExecCommand failed: %v", err)
+	}
+}
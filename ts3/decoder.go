@@ -4,118 +4,538 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
+)
+
+// Unmarshaler is implemented by types that decode a ServerQuery value
+// themselves. value is already unescaped.
+type Unmarshaler interface {
+	UnmarshalTS3(value string) error
+}
+
+var (
+	unmarshalerType = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
+	timeType        = reflect.TypeOf(time.Time{})
+	durationType    = reflect.TypeOf(time.Duration(0))
+	stringMapType   = reflect.TypeOf(map[string]string(nil))
 )
 
 // Decoder converts TS3 ServerQuery response text into Go structs/slices.
-type Decoder struct{}
+//
+// Besides strings, numbers, bools and comma separated slices it decodes
+// time values according to the tag options:
+//
+//	Created time.Time     `ts3:"client_created,unix"`   // unix seconds (default)
+//	Stamp   time.Time     `ts3:"timestamp,ms"`           // unix milliseconds
+//	Idle    time.Duration `ts3:"client_idle_time,ms"`    // milliseconds
+//	Uptime  time.Duration `ts3:"virtualserver_uptime,s"` // seconds (default)
+//
+// A time of 0 decodes to the zero time.Time. Fields whose pointer type
+// implements Unmarshaler decode themselves.
+//
+// Embedded structs are flattened. Pointer fields stay nil when their key is
+// absent. A map[string]string field tagged `ts3:",extra"` receives all keys
+// that no other field consumed:
+//
+//	type ClientInfo struct {
+//		models.OnlineClient
+//		Description *string           `ts3:"client_description"`
+//		Extra       map[string]string `ts3:",extra"`
+//	}
+//
+// By default keys without a matching field are ignored and fields without a
+// matching key keep their zero value. Use Strict, DisallowUnknownFields or
+// RequireFields to turn such drift into a *DecodeError, or DecodeLenient to
+// get it reported alongside the decoded value.
+//
+// Field layouts are analysed once per type and cached, so creating a Decoder
+// per call is cheap. A configured Decoder is safe for concurrent use.
+type Decoder struct {
+	disallowUnknown bool
+	requireFields   bool
+}
 
 // NewDecoder creates a Decoder instance.
 func NewDecoder() *Decoder {
 	return &Decoder{}
 }
 
-// Decode parses response into v, where v must be a non-nil pointer to struct
-// or slice of structs.
+// DisallowUnknownFields makes Decode fail when the response contains keys
+// that no tagged field consumes. Keys collected by a ",extra" field still
+// count as unknown.
+func (d *Decoder) DisallowUnknownFields() *Decoder {
+	d.disallowUnknown = true
+	return d
+}
+
+// RequireFields makes Decode fail when a tagged field has no key in the
+// response. Pointer fields, fields of nil embedded pointers and fields tagged
+// with the ",optional" option are not required.
+func (d *Decoder) RequireFields() *Decoder {
+	d.requireFields = true
+	return d
+}
+
+// Strict enables DisallowUnknownFields and RequireFields.
+func (d *Decoder) Strict() *Decoder {
+	return d.DisallowUnknownFields().RequireFields()
+}
+
+// DecodeError reports keys that did not match the decode target. For slices
+// the keys of all rows are merged.
+type DecodeError struct {
+	// Type is the decoded struct type, e.g. "models.ClientInfo".
+	Type string
+	// Missing lists required tagged keys that were absent.
+	Missing []string
+	// Unknown lists response keys without a tagged field.
+	Unknown []string
+}
+
+func (e *DecodeError) Error() string {
+	var parts []string
+	if len(e.Missing) > 0 {
+		parts = append(parts, "missing keys: "+strings.Join(e.Missing, ", "))
+	}
+	if len(e.Unknown) > 0 {
+		parts = append(parts, "unknown keys: "+strings.Join(e.Unknown, ", "))
+	}
+	return fmt.Sprintf("ts3: decode %s: %s", e.Type, strings.Join(parts, "; "))
+}
+
+// Decode parses response into v, where v must be a non-nil pointer to a
+// struct, a map[string]string, or a slice of either.
 func (d *Decoder) Decode(response string, v interface{}) error {
+	if !d.requireFields && !d.disallowUnknown {
+		_, err := d.decode(response, v, false)
+		return err
+	}
+
+	report, err := d.decode(response, v, true)
+	if err != nil || report == nil {
+		return err
+	}
+
+	strictErr := &DecodeError{Type: report.Type}
+	if d.requireFields {
+		strictErr.Missing = report.Missing
+	}
+	if d.disallowUnknown {
+		strictErr.Unknown = report.Unknown
+	}
+	if len(strictErr.Missing) == 0 && len(strictErr.Unknown) == 0 {
+		return nil
+	}
+	return strictErr
+}
+
+// DecodeLenient decodes like a non-strict Decode and additionally returns
+// the missing and unknown keys as warnings, which is useful to log model
+// drift against new server builds. warnings is nil when everything matched.
+//
+//	warnings, err := ts3.NewDecoder().DecodeLenient(raw, &info)
+//	if warnings != nil {
+//		log.Printf("model drift: %v", warnings)
+//	}
+func (d *Decoder) DecodeLenient(response string, v interface{}) (warnings *DecodeError, err error) {
+	return d.decode(response, v, true)
+}
+
+func (d *Decoder) decode(response string, v interface{}, wantReport bool) (*DecodeError, error) {
 	rv := reflect.ValueOf(v)
 	if rv.Kind() != reflect.Ptr || rv.IsNil() {
-		return errors.New("ts3: Decode requires a non-nil pointer")
+		return nil, errors.New("ts3: Decode requires a non-nil pointer")
 	}
 
 	elem := rv.Elem()
-	rows := parseRawResponse(response)
-
 	switch elem.Kind() {
 	case reflect.Struct:
-		if len(rows) == 0 {
-			return nil
+		resp := tokenizeResponse(response)
+		if resp.len() == 0 {
+			return nil, nil
 		}
-		return d.decodeStruct(rows[0], elem)
+		plan, err := planFor(elem.Type())
+		if err != nil {
+			return nil, err
+		}
+		st := newDecodeState(plan, wantReport)
+		if err := st.decodeRow(resp.row(0), elem); err != nil {
+			return nil, err
+		}
+		return st.report.result(elem.Type()), nil
+	case reflect.Map:
+		if elem.Type() != stringMapType {
+			return nil, errors.New("ts3: Decode map target must be map[string]string")
+		}
+		resp := tokenizeResponse(response)
+		if resp.len() == 0 {
+			return nil, nil
+		}
+		elem.Set(reflect.ValueOf(resp.rowMap(0)))
+		return nil, nil
 	case reflect.Slice:
-		return d.decodeSlice(rows, elem)
+		return d.decodeSlice(tokenizeResponse(response), elem, wantReport)
 	default:
-		return errors.New("ts3: Decode target must be a struct or slice of structs")
+		return nil, errors.New("ts3: Decode target must be a struct, map[string]string or a slice of them")
 	}
 }
 
-func parseRawResponse(response string) []map[string]string {
-	response = strings.TrimSpace(response)
-	if response == "" {
-		return nil
-	}
+// decodeReport collects missing and unknown keys over all decoded rows.
+type decodeReport struct {
+	missing map[string]bool
+	unknown map[string]bool
+}
 
-	lines := strings.Split(response, "|")
-	out := make([]map[string]string, 0, len(lines))
+func (r *decodeReport) add(set *map[string]bool, key string) {
+	if *set == nil {
+		*set = make(map[string]bool)
+	}
+	(*set)[key] = true
+}
 
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
+func (r *decodeReport) result(t reflect.Type) *DecodeError {
+	if r == nil || (len(r.missing) == 0 && len(r.unknown) == 0) {
+		return nil
+	}
+	return &DecodeError{Type: t.String(), Missing: sortedKeys(r.missing), Unknown: sortedKeys(r.unknown)}
+}
 
-		row := make(map[string]string)
-		for _, item := range strings.Fields(line) {
-			kv := strings.SplitN(item, "=", 2)
-			key := kv[0]
-			val := ""
-			if len(kv) == 2 {
-				val = Unescape(kv[1])
-			}
-			row[key] = val
-		}
-		out = append(out, row)
+func sortedKeys(set map[string]bool) []string {
+	if len(set) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
 	}
-	return out
+	sort.Strings(keys)
+	return keys
 }
 
-func (d *Decoder) decodeSlice(rows []map[string]string, target reflect.Value) error {
+func (d *Decoder) decodeSlice(resp tokenizedResponse, target reflect.Value, wantReport bool) (*DecodeError, error) {
 	elemType := target.Type().Elem()
-	if elemType.Kind() != reflect.Struct {
-		return errors.New("ts3: Decode slice target must be []struct")
+	rows := resp.len()
+	switch {
+	case elemType == stringMapType:
+		decoded := make([]map[string]string, rows)
+		for i := range decoded {
+			decoded[i] = resp.rowMap(i)
+		}
+		target.Set(reflect.ValueOf(decoded).Convert(target.Type()))
+		return nil, nil
+	case elemType.Kind() != reflect.Struct:
+		return nil, errors.New("ts3: Decode slice target must be []struct or []map[string]string")
+	}
+
+	decoded := reflect.MakeSlice(target.Type(), rows, rows)
+	if rows == 0 {
+		target.Set(decoded)
+		return nil, nil
 	}
 
-	decoded := reflect.MakeSlice(target.Type(), 0, len(rows))
-	for i, row := range rows {
-		item := reflect.New(elemType).Elem()
-		if err := d.decodeStruct(row, item); err != nil {
-			return fmt.Errorf("ts3: decode row %d: %w", i, err)
+	plan, err := planFor(elemType)
+	if err != nil {
+		return nil, err
+	}
+	st := newDecodeState(plan, wantReport)
+	for i := 0; i < rows; i++ {
+		if err := st.decodeRow(resp.row(i), decoded.Index(i)); err != nil {
+			return nil, fmt.Errorf("ts3: decode row %d: %w", i, err)
 		}
-		decoded = reflect.Append(decoded, item)
 	}
 	target.Set(decoded)
-	return nil
+	return st.report.result(elemType), nil
+}
+
+// fieldKind selects the setter of a planned field.
+type fieldKind int
+
+const (
+	fieldOther fieldKind = iota
+	fieldUnmarshaler
+	fieldString
+	fieldInt
+	fieldTime
+	fieldDuration
+)
+
+// fieldPlan describes one tagged field of a struct type.
+type fieldPlan struct {
+	name     string // response key
+	goName   string // Go field name, for errors
+	index    []int  // path from the root struct through embedded structs
+	opts     tagOptions
+	kind     fieldKind
+	optional bool // not reported as missing
 }
 
-func (d *Decoder) decodeStruct(data map[string]string, target reflect.Value) error {
-	t := target.Type()
-	for i := 0; i < target.NumField(); i++ {
-		field := target.Field(i)
+// structPlan is the cached decode plan of a struct type: its tagged fields,
+// including those of embedded structs, indexed by response key.
+type structPlan struct {
+	fields []fieldPlan
+	byName map[string][]int
+	extras [][]int
+}
+
+type planEntry struct {
+	plan *structPlan
+	err  error
+}
+
+// decodePlans caches a *planEntry per struct type.
+var decodePlans sync.Map
+
+func planFor(t reflect.Type) (*structPlan, error) {
+	if e, ok := decodePlans.Load(t); ok {
+		entry := e.(*planEntry)
+		return entry.plan, entry.err
+	}
+
+	plan := &structPlan{byName: make(map[string][]int)}
+	err := plan.add(t, nil, map[reflect.Type]bool{})
+	e, _ := decodePlans.LoadOrStore(t, &planEntry{plan: plan, err: err})
+	entry := e.(*planEntry)
+	return entry.plan, entry.err
+}
+
+func (p *structPlan) add(t reflect.Type, prefix []int, visiting map[reflect.Type]bool) error {
+	if visiting[t] {
+		return nil
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	for i := 0; i < t.NumField(); i++ {
 		structField := t.Field(i)
+		index := append(append([]int(nil), prefix...), i)
 
 		tag := structField.Tag.Get("ts3")
-		if tag == "" {
+		if structField.Anonymous && tag == "" {
+			ft := structField.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				if err := p.add(ft, index, visiting); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if !structField.IsExported() {
 			continue
 		}
 
-		raw, ok := data[tag]
-		if !ok {
+		name, opts := parseTag(tag)
+		if opts.has("extra") {
+			if structField.Type != stringMapType {
+				return fmt.Errorf("field %s: extra requires map[string]string", structField.Name)
+			}
+			p.extras = append(p.extras, index)
+			continue
+		}
+		if name == "" || strings.HasPrefix(name, "-") {
 			continue
 		}
 
-		if err := setField(field, raw); err != nil {
-			return fmt.Errorf("field %s (%s): %w", structField.Name, tag, err)
+		p.byName[name] = append(p.byName[name], len(p.fields))
+		p.fields = append(p.fields, fieldPlan{
+			name:     name,
+			goName:   structField.Name,
+			index:    index,
+			opts:     opts,
+			kind:     kindOf(structField.Type),
+			optional: structField.Type.Kind() == reflect.Ptr || opts.has("optional"),
+		})
+	}
+	return nil
+}
+
+func kindOf(t reflect.Type) fieldKind {
+	switch {
+	case reflect.PointerTo(t).Implements(unmarshalerType):
+		return fieldUnmarshaler
+	case t == timeType:
+		return fieldTime
+	case t == durationType:
+		return fieldDuration
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return fieldString
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return fieldInt
+	}
+	return fieldOther
+}
+
+func (f *fieldPlan) set(field reflect.Value, value string) error {
+	switch f.kind {
+	case fieldUnmarshaler:
+		return field.Addr().Interface().(Unmarshaler).UnmarshalTS3(value)
+	case fieldString:
+		field.SetString(value)
+		return nil
+	case fieldInt:
+		if value == "" {
+			field.SetInt(0)
+			return nil
+		}
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+		return nil
+	case fieldTime:
+		return setTimeField(field, value, f.opts)
+	case fieldDuration:
+		return setDurationField(field, value, f.opts)
+	default:
+		return setField(field, value, f.opts)
+	}
+}
+
+// fieldByIndex returns the field at index. Nil embedded pointers on the way
+// are allocated when alloc is set; otherwise, or when they cannot be set,
+// ok is false.
+func fieldByIndex(v reflect.Value, index []int, alloc bool) (field reflect.Value, ok bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !alloc || !v.CanSet() {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// decodeState holds the scratch space for decoding rows with one plan.
+type decodeState struct {
+	plan   *structPlan
+	seen   []bool
+	unused []int
+	report *decodeReport
+}
+
+func newDecodeState(plan *structPlan, wantReport bool) *decodeState {
+	st := &decodeState{plan: plan, seen: make([]bool, len(plan.fields))}
+	if wantReport {
+		st.report = &decodeReport{}
+	}
+	return st
+}
+
+// decodeRow fills the tagged fields of target, including those of embedded
+// structs, and puts the remaining keys into ",extra" fields. A nil embedded
+// pointer is only allocated when one of its fields is present; its fields are
+// optional.
+func (st *decodeState) decodeRow(row []responseField, target reflect.Value) error {
+	clear(st.seen)
+	st.unused = st.unused[:0]
+
+	for i, kv := range row {
+		used := false
+		for _, fi := range st.plan.byName[kv.key] {
+			f := &st.plan.fields[fi]
+			field, ok := fieldByIndex(target, f.index, true)
+			if !ok || !field.CanSet() {
+				continue
+			}
+			if err := f.set(field, kv.value); err != nil {
+				return fmt.Errorf("field %s (%s): %w", f.goName, f.name, err)
+			}
+			st.seen[fi] = true
+			used = true
+		}
+		if !used {
+			st.unused = append(st.unused, i)
+		}
+	}
+
+	if st.report != nil {
+		for fi, seen := range st.seen {
+			f := &st.plan.fields[fi]
+			if seen || f.optional {
+				continue
+			}
+			if _, ok := fieldByIndex(target, f.index, false); ok {
+				st.report.add(&st.report.missing, f.name)
+			}
+		}
+		for _, i := range st.unused {
+			st.report.add(&st.report.unknown, row[i].key)
+		}
+	}
+
+	if len(st.unused) == 0 || len(st.plan.extras) == 0 {
+		return nil
+	}
+	extra := make(map[string]string, len(st.unused))
+	for _, i := range st.unused {
+		extra[row[i].key] = row[i].value
+	}
+	for _, index := range st.plan.extras {
+		if field, ok := fieldByIndex(target, index, false); ok {
+			field.Set(reflect.ValueOf(extra))
 		}
 	}
 	return nil
 }
 
-func setField(field reflect.Value, value string) error {
+// tagOptions are the comma-separated options after the name in a ts3 tag.
+type tagOptions []string
+
+// parseTag splits a ts3 struct tag into name and options.
+func parseTag(tag string) (string, tagOptions) {
+	name, opts, ok := strings.Cut(tag, ",")
+	if !ok {
+		return name, nil
+	}
+	return name, strings.Split(opts, ",")
+}
+
+func (o tagOptions) has(option string) bool {
+	for _, opt := range o {
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
+
+func setField(field reflect.Value, value string, opts tagOptions) error {
 	if !field.CanSet() {
 		return nil
 	}
 
+	if field.CanAddr() && field.Addr().Type().Implements(unmarshalerType) {
+		return field.Addr().Interface().(Unmarshaler).UnmarshalTS3(value)
+	}
+	if field.Kind() == reflect.Ptr {
+		ptr := reflect.New(field.Type().Elem())
+		if err := setField(ptr.Elem(), value, opts); err != nil {
+			return err
+		}
+		field.Set(ptr)
+		return nil
+	}
+	switch field.Type() {
+	case timeType:
+		return setTimeField(field, value, opts)
+	case durationType:
+		return setDurationField(field, value, opts)
+	}
+
 	switch field.Kind() {
 	case reflect.String:
 		field.SetString(value)
@@ -173,6 +593,46 @@ func setField(field reflect.Value, value string) error {
 	return nil
 }
 
+func setTimeField(field reflect.Value, value string, opts tagOptions) error {
+	n, err := parseTimeNumber(value)
+	if err != nil {
+		return err
+	}
+
+	var t time.Time
+	switch {
+	case n == 0:
+	case opts.has("ms"):
+		t = time.UnixMilli(n)
+	default:
+		t = time.Unix(n, 0)
+	}
+	// Setting through the pointer avoids boxing t.
+	*field.Addr().Interface().(*time.Time) = t
+	return nil
+}
+
+func setDurationField(field reflect.Value, value string, opts tagOptions) error {
+	n, err := parseTimeNumber(value)
+	if err != nil {
+		return err
+	}
+
+	unit := time.Second
+	if opts.has("ms") {
+		unit = time.Millisecond
+	}
+	field.SetInt(n * int64(unit))
+	return nil
+}
+
+func parseTimeNumber(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
 func setSliceField(field reflect.Value, value string) error {
 	if value == "" {
 		field.Set(reflect.MakeSlice(field.Type(), 0, 0))
@@ -181,31 +641,28 @@ func setSliceField(field reflect.Value, value string) error {
 
 	switch field.Type().Elem().Kind() {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		parts := strings.Split(value, ",")
-		out := reflect.MakeSlice(field.Type(), 0, len(parts))
-		for _, part := range parts {
+		size := strings.Count(value, ",") + 1
+		out := reflect.MakeSlice(field.Type(), size, size)
+		n := 0
+		for part := range strings.SplitSeq(value, ",") {
 			part = strings.TrimSpace(part)
 			if part == "" {
 				continue
 			}
-			n, err := strconv.ParseInt(part, 10, 64)
+			v, err := strconv.ParseInt(part, 10, 64)
 			if err != nil {
 				return err
 			}
-			item := reflect.New(field.Type().Elem()).Elem()
-			item.SetInt(n)
-			out = reflect.Append(out, item)
+			out.Index(n).SetInt(v)
+			n++
 		}
-		field.Set(out)
+		field.Set(out.Slice(0, n))
 		return nil
 
 	case reflect.String:
-		parts := strings.Split(value, ",")
-		out := reflect.MakeSlice(field.Type(), 0, len(parts))
-		for _, part := range parts {
-			item := reflect.New(field.Type().Elem()).Elem()
-			item.SetString(strings.TrimSpace(part))
-			out = reflect.Append(out, item)
+		out := reflect.MakeSlice(field.Type(), 0, strings.Count(value, ",")+1)
+		for part := range strings.SplitSeq(value, ",") {
+			out = reflect.Append(out, reflect.ValueOf(strings.TrimSpace(part)).Convert(field.Type().Elem()))
 		}
 		field.Set(out)
 		return nil
@@ -1,6 +1,15 @@
 package ts3
 
-import "testing"
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jkesh/ts3-go/v2/ts3/models"
+)
 
 func TestDecoderDecodeStruct(t *testing.T) {
 	raw := "id=7 name=hello\\sworld active=1 groups=1,2,3"
@@ -45,3 +54,250 @@ func TestDecoderRejectsInvalidTarget(t *testing.T) {
 		t.Fatalf("expected error for non-pointer target")
 	}
 }
+
+type testLevel string
+
+func (l *testLevel) UnmarshalTS3(value string) error {
+	*l = testLevel("level-" + value)
+	return nil
+}
+
+func TestDecoderTimeAndUnmarshaler(t *testing.T) {
+	raw := "created=1700000000 lastseen=0 stamp=1700000000123 idle=1500 uptime=90 level=3"
+
+	var out struct {
+		Created  time.Time     `ts3:"created,unix"`
+		LastSeen time.Time     `ts3:"lastseen"`
+		Stamp    time.Time     `ts3:"stamp,ms"`
+		Idle     time.Duration `ts3:"idle,ms"`
+		Uptime   time.Duration `ts3:"uptime,s"`
+		Level    testLevel     `ts3:"level"`
+	}
+	if err := NewDecoder().Decode(raw, &out); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+
+	if !out.Created.Equal(time.Unix(1700000000, 0)) {
+		t.Fatalf("created = %v", out.Created)
+	}
+	if !out.LastSeen.IsZero() {
+		t.Fatalf("lastseen = %v, want zero time", out.LastSeen)
+	}
+	if !out.Stamp.Equal(time.UnixMilli(1700000000123)) {
+		t.Fatalf("stamp = %v", out.Stamp)
+	}
+	if out.Idle != 1500*time.Millisecond || out.Uptime != 90*time.Second {
+		t.Fatalf("idle = %v, uptime = %v", out.Idle, out.Uptime)
+	}
+	if out.Level != "level-3" {
+		t.Fatalf("level = %q", out.Level)
+	}
+
+	if err := NewDecoder().Decode("created=soon", &out); err == nil {
+		t.Fatalf("expected error for invalid time value")
+	}
+}
+
+func TestDecoderEmbeddedPointersAndExtra(t *testing.T) {
+	type base struct {
+		ID   int    `ts3:"id"`
+		Name string `ts3:"name"`
+	}
+	type details struct {
+		Topic string `ts3:"topic"`
+	}
+	raw := "id=3 name=Lobby codec=4 new_prop=x\\sy flag"
+
+	var out struct {
+		base
+		*details
+		Codec *int              `ts3:"codec"`
+		Icon  *string           `ts3:"icon"`
+		Extra map[string]string `ts3:",extra"`
+	}
+	if err := NewDecoder().Decode(raw, &out); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+
+	if out.ID != 3 || out.Name != "Lobby" {
+		t.Fatalf("embedded fields mismatch: %+v", out.base)
+	}
+	if out.details != nil {
+		t.Fatalf("embedded pointer allocated without matching keys: %+v", out.details)
+	}
+	if out.Codec == nil || *out.Codec != 4 {
+		t.Fatalf("codec = %v, want 4", out.Codec)
+	}
+	if out.Icon != nil {
+		t.Fatalf("icon = %q, want nil", *out.Icon)
+	}
+	if len(out.Extra) != 2 || out.Extra["new_prop"] != "x y" || out.Extra["flag"] != "" {
+		t.Fatalf("extra = %v", out.Extra)
+	}
+}
+
+func TestDecoderMapTargets(t *testing.T) {
+	var row map[string]string
+	if err := NewDecoder().Decode("id=1 name=a\\sb", &row); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if row["id"] != "1" || row["name"] != "a b" {
+		t.Fatalf("unexpected map: %v", row)
+	}
+
+	var rows []map[string]string
+	if err := NewDecoder().Decode("id=1|id=2", &rows); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if len(rows) != 2 || rows[1]["id"] != "2" {
+		t.Fatalf("unexpected rows: %v", rows)
+	}
+
+	var bad map[string]int
+	if err := NewDecoder().Decode("id=1", &bad); err == nil {
+		t.Fatalf("expected error for map[string]int target")
+	}
+}
+
+func TestDecoderStrictReportsDrift(t *testing.T) {
+	type row struct {
+		ID    int    `ts3:"id"`
+		Name  string `ts3:"name"`
+		Topic string `ts3:"topic,optional"`
+		Icon  *int   `ts3:"icon"`
+		Codec int    `ts3:"codec"`
+	}
+	raw := "id=1 name=a new_a=1|id=2 new_b=2 codec=3"
+
+	var rows []row
+	if err := NewDecoder().Decode(raw, &rows); err != nil {
+		t.Fatalf("non-strict Decode failed: %v", err)
+	}
+
+	err := NewDecoder().Strict().Decode(raw, &rows)
+	var decErr *DecodeError
+	if !errors.As(err, &decErr) {
+		t.Fatalf("expected *DecodeError, got %v", err)
+	}
+	if !reflect.DeepEqual(decErr.Missing, []string{"codec", "name"}) ||
+		!reflect.DeepEqual(decErr.Unknown, []string{"new_a", "new_b"}) {
+		t.Fatalf("unexpected drift: %+v", decErr)
+	}
+	if len(rows) != 2 || rows[1].Codec != 3 {
+		t.Fatalf("rows not decoded: %+v", rows)
+	}
+
+	err = NewDecoder().RequireFields().Decode("id=1 name=a codec=2 new=1", &rows)
+	if err != nil {
+		t.Fatalf("RequireFields should ignore unknown keys: %v", err)
+	}
+	err = NewDecoder().DisallowUnknownFields().Decode("id=1", &rows)
+	if err != nil {
+		t.Fatalf("DisallowUnknownFields should ignore missing keys: %v", err)
+	}
+}
+
+func TestDecoderDecodeLenient(t *testing.T) {
+	var out struct {
+		ID   int    `ts3:"id"`
+		Name string `ts3:"name"`
+	}
+	warnings, err := NewDecoder().DecodeLenient("id=5 future=1", &out)
+	if err != nil {
+		t.Fatalf("DecodeLenient failed: %v", err)
+	}
+	if out.ID != 5 {
+		t.Fatalf("value not decoded: %+v", out)
+	}
+	if warnings == nil || !reflect.DeepEqual(warnings.Missing, []string{"name"}) ||
+		!reflect.DeepEqual(warnings.Unknown, []string{"future"}) {
+		t.Fatalf("unexpected warnings: %+v", warnings)
+	}
+
+	warnings, err = NewDecoder().DecodeLenient("id=5 name=x", &out)
+	if err != nil || warnings != nil {
+		t.Fatalf("expected no warnings, got %v / %v", warnings, err)
+	}
+}
+
+// benchClientList builds a "clientlist -uid -groups -times" response.
+func benchClientList(rows int) string {
+	var b strings.Builder
+	for i := 0; i < rows; i++ {
+		if i > 0 {
+			b.WriteByte('|')
+		}
+		fmt.Fprintf(&b, "clid=%d cid=%d client_database_id=%d client_nickname=User\\s%d client_type=0 "+
+			"client_unique_identifier=abcdefghijklmnopqrstuvw%d= client_servergroups=6,8,%d "+
+			"client_idle_time=%d client_created=1700000000 client_lastconnected=1700001000 "+
+			"client_away=0 client_away_message client_input_muted=0 client_output_muted=0",
+			i+1, i%20, i+100, i, i, i%5, i*1000)
+	}
+	return b.String()
+}
+
+func BenchmarkDecodeClientList(b *testing.B) {
+	raw := benchClientList(500)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(raw)))
+	for b.Loop() {
+		var clients []models.OnlineClient
+		if err := NewDecoder().Decode(raw, &clients); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseResponse(b *testing.B) {
+	raw := benchClientList(500)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(raw)))
+	for b.Loop() {
+		var rows []map[string]string
+		if err := NewDecoder().Decode(raw, &rows); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnescape(b *testing.B) {
+	values := []string{"plain", "Hello\\sWorld\\p\\/path", "abcdefghijklmnopqrstuvw="}
+	b.ReportAllocs()
+	for b.Loop() {
+		for _, v := range values {
+			_ = Unescape(v)
+		}
+	}
+}
+
+func TestTokenizeResponse(t *testing.T) {
+	resp := tokenizeResponse(" id=1 name=a\\sb flag uid=x= |\n| id=2 path=\\/tmp\\p\\q\\ ")
+	if resp.len() != 2 {
+		t.Fatalf("rows = %d, want 2", resp.len())
+	}
+
+	want := []map[string]string{
+		{"id": "1", "name": "a b", "flag": "", "uid": "x="},
+		{"id": "2", "path": "/tmp|\\q\\"},
+	}
+	for i := range want {
+		if got := resp.rowMap(i); !reflect.DeepEqual(got, want[i]) {
+			t.Fatalf("row %d = %q, want %q", i, got, want[i])
+		}
+	}
+
+	if resp := tokenizeResponse(" | "); resp.len() != 0 {
+		t.Fatalf("expected no rows, got %d", resp.len())
+	}
+}
+
+func TestUnescapeEscapeRoundTrip(t *testing.T) {
+	for _, s := range []string{"", "plain", "a b|c/d\\e\tf\ng\r\v\f\a\b"} {
+		if got := Unescape(Escape(s)); got != s {
+			t.Fatalf("Unescape(Escape(%q)) = %q", s, got)
+		}
+	}
+	if got := Unescape("\\\\s\\x"); got != "\\s\\x" {
+		t.Fatalf("unexpected unescape of unknown sequences: %q", got)
+	}
+}
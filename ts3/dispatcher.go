@@ -0,0 +1,471 @@
+package ts3
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const defaultEventBuffer = 256
+
+// OverflowPolicy decides what happens when a subscription queue is full.
+type OverflowPolicy int
+
+const (
+	// OverflowDefault uses Config.EventOverflow, which defaults to
+	// OverflowDropOldest.
+	OverflowDefault OverflowPolicy = iota
+	// OverflowBlock never drops: when the channel is full, further events
+	// wait in an unbounded queue of the subscription until the consumer
+	// catches up. Only that subscription waits; other subscriptions and
+	// command replies go on, so the consumer may run commands. The queue
+	// grows as long as the consumer lags.
+	OverflowBlock
+	// OverflowDropOldest discards the oldest queued event.
+	OverflowDropOldest
+	// OverflowDropNewest discards the arriving event.
+	OverflowDropNewest
+)
+
+// String returns the policy name.
+func (p OverflowPolicy) String() string {
+	switch p {
+	case OverflowDefault:
+		return "default"
+	case OverflowBlock:
+		return "block"
+	case OverflowDropOldest:
+		return "drop-oldest"
+	case OverflowDropNewest:
+		return "drop-newest"
+	default:
+		return "unknown"
+	}
+}
+
+// Event is one notification line as received from the server.
+type Event struct {
+	// Name is the notification name, e.g. "notifytextmessage".
+	Name string
+	// Data is the escaped payload after the name. Multi-row notifications
+	// keep their "|" separators.
+	Data string
+	// Received is the time the line was read.
+	Received time.Time
+
+	d *Dispatcher
+}
+
+// Decode decodes the payload into a struct (first row) or a slice (all
+// rows). Keys that only the first row carries are copied to later rows.
+func (e Event) Decode(v interface{}) error {
+	return NewDecoder().Decode(strings.Join(notifyRows(e.Data), "|"), v)
+}
+
+// EventFilter selects the events of a subscription and sizes its queue.
+type EventFilter struct {
+	// Names limits the subscription to these notification names. Empty
+	// means all events.
+	Names []string
+	// Buffer is the queue size. 0 uses Config.EventBuffer.
+	Buffer int
+	// Overflow applies when the queue is full.
+	Overflow OverflowPolicy
+}
+
+// Dispatcher delivers notifications to subscriptions in arrival order and
+// runs the middleware chain of handlers. Get it with Client.Dispatcher.
+type Dispatcher struct {
+	mu          sync.RWMutex
+	subs        []*Subscription
+	nextID      uint64
+	closed      bool
+	buffer      int
+	overflow    OverflowPolicy
+	dropped     atomic.Uint64
+	quit        <-chan struct{}
+	logf        func(format string, v ...interface{})
+	middlewares []Middleware
+	chainGen    atomic.Uint64
+
+	// queryClients holds the clids that entered as query clients.
+	queryMu      sync.Mutex
+	queryClients map[string]bool
+}
+
+func newDispatcher(buffer int, overflow OverflowPolicy, quit <-chan struct{}, logf func(string, ...interface{})) *Dispatcher {
+	if buffer <= 0 {
+		buffer = defaultEventBuffer
+	}
+	if overflow == OverflowDefault {
+		overflow = OverflowDropOldest
+	}
+	return &Dispatcher{buffer: buffer, overflow: overflow, quit: quit, logf: logf}
+}
+
+// Subscription is one ordered event queue, fed either to a Register
+// callback or to an Events channel. Callback queues are unbounded, so a
+// handler can run commands without holding up the connection.
+type Subscription struct {
+	c        *Client
+	d        *Dispatcher
+	id       uint64
+	names    map[string]bool
+	overflow OverflowPolicy
+	dropped  atomic.Uint64
+	// handlerFor is the event name of a Register callback.
+	handlerFor string
+	// scopes are the notification scopes held by the handler.
+	scopes []NotifyScope
+
+	ch chan Event
+	// queue and wake replace ch for callbacks. Blocking Events channels use
+	// them too; their forward goroutine moves the queue into ch.
+	blocking  bool
+	queue     []Event
+	wake      chan struct{}
+	done      chan struct{}
+	sendMu    sync.Mutex
+	closeOnce sync.Once
+	stop      func() bool
+}
+
+// SubscriptionInfo describes a subscription for debugging.
+type SubscriptionInfo struct {
+	ID uint64
+	// Names are the subscribed notification names; nil means all.
+	Names []string
+	// Handler is true for Register callbacks and false for Events channels.
+	Handler bool
+	// Scopes are the notification scopes the subscription holds.
+	Scopes []NotifyScope
+	// Queued counts the events waiting in the channel and, for handlers and
+	// OverflowBlock, in the unbounded queue.
+	Queued int
+	// Buffer and Overflow are zero for handlers, whose queues are unbounded.
+	Buffer   int
+	Overflow OverflowPolicy
+	Dropped  uint64
+}
+
+// subscribe adds a subscription. handlerFor marks Register callbacks, which
+// get an unbounded queue read with take; Events channels with OverflowBlock
+// get one as well, fed into the channel by forward. The subscription is
+// closed right away when the dispatcher is closed.
+func (d *Dispatcher) subscribe(c *Client, filter EventFilter, handlerFor string, scopes []NotifyScope) *Subscription {
+	s := &Subscription{
+		c:          c,
+		d:          d,
+		handlerFor: handlerFor,
+		scopes:     scopes,
+		done:       make(chan struct{}),
+	}
+	if handlerFor != "" {
+		s.ch = make(chan Event)
+		s.wake = make(chan struct{}, 1)
+	} else {
+		buffer := filter.Buffer
+		if buffer <= 0 {
+			buffer = d.buffer
+		}
+		s.overflow = filter.Overflow
+		if s.overflow == OverflowDefault {
+			s.overflow = d.overflow
+		}
+		s.ch = make(chan Event, buffer)
+		if s.overflow == OverflowBlock {
+			s.blocking = true
+			s.wake = make(chan struct{}, 1)
+		}
+	}
+	for _, name := range filter.Names {
+		if name = strings.TrimSpace(name); name != "" {
+			if s.names == nil {
+				s.names = make(map[string]bool)
+			}
+			s.names[name] = true
+		}
+	}
+
+	d.mu.Lock()
+	closed := d.closed
+	if !closed {
+		d.nextID++
+		s.id = d.nextID
+		d.subs = append(d.subs, s)
+	}
+	d.mu.Unlock()
+	if s.blocking {
+		go s.forward()
+	}
+	if closed {
+		s.close()
+	}
+	return s
+}
+
+// publish hands ev to every matching subscription, one after another.
+func (d *Dispatcher) publish(ev Event) {
+	ev.d = d
+	if ev.Name == EventClientEnterView {
+		d.trackQueryClients(ev.Data)
+	}
+
+	d.mu.RLock()
+	subs := append([]*Subscription(nil), d.subs...)
+	d.mu.RUnlock()
+
+	for _, s := range subs {
+		if s.names == nil || s.names[ev.Name] {
+			s.send(ev)
+		}
+	}
+}
+
+// remove forgets s without closing it.
+func (d *Dispatcher) remove(s *Subscription) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for i, sub := range d.subs {
+		if sub == s {
+			d.subs = append(d.subs[:i:i], d.subs[i+1:]...)
+			return
+		}
+	}
+}
+
+// closeAll closes every subscription and rejects new ones.
+func (d *Dispatcher) closeAll() {
+	d.mu.Lock()
+	d.closed = true
+	subs := d.subs
+	d.subs = nil
+	d.mu.Unlock()
+
+	for _, s := range subs {
+		s.close()
+	}
+}
+
+// handlers returns the Register callbacks of eventName.
+func (d *Dispatcher) handlers(eventName string) []*Subscription {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	var subs []*Subscription
+	for _, s := range d.subs {
+		if s.handlerFor == eventName {
+			subs = append(subs, s)
+		}
+	}
+	return subs
+}
+
+// infos describes the open subscriptions in creation order.
+func (d *Dispatcher) infos() []SubscriptionInfo {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	out := make([]SubscriptionInfo, 0, len(d.subs))
+	for _, s := range d.subs {
+		info := SubscriptionInfo{
+			ID:       s.id,
+			Handler:  s.handlerFor != "",
+			Scopes:   append([]NotifyScope(nil), s.scopes...),
+			Queued:   len(s.ch),
+			Buffer:   cap(s.ch),
+			Overflow: s.overflow,
+			Dropped:  s.dropped.Load(),
+		}
+		if s.wake != nil {
+			s.sendMu.Lock()
+			info.Queued += len(s.queue)
+			s.sendMu.Unlock()
+		}
+		for name := range s.names {
+			info.Names = append(info.Names, name)
+		}
+		sort.Strings(info.Names)
+		out = append(out, info)
+	}
+	return out
+}
+
+func (s *Subscription) send(ev Event) {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+
+	select {
+	case <-s.done:
+		return
+	default:
+	}
+
+	if s.wake != nil {
+		s.queue = append(s.queue, ev)
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+		return
+	}
+
+	if s.overflow == OverflowDropNewest {
+		select {
+		case s.ch <- ev:
+		default:
+			s.drop()
+		}
+		return
+	}
+	for {
+		select {
+		case s.ch <- ev:
+			return
+		default:
+		}
+		select {
+		case <-s.ch:
+			s.drop()
+		default:
+		}
+	}
+}
+
+// forward feeds the queue of a blocking Events subscription into its
+// channel, waiting for the consumer in place of publish. After the
+// subscription was closed, remaining events are only kept while the channel
+// has room. It closes the channel when done.
+func (s *Subscription) forward() {
+	defer close(s.ch)
+	for {
+		events, ok := s.take()
+		for _, ev := range events {
+			select {
+			case s.ch <- ev:
+				continue
+			case <-s.done:
+			}
+			select {
+			case s.ch <- ev:
+			default:
+				return
+			}
+		}
+		if !ok {
+			return
+		}
+	}
+}
+
+// take waits for the queued events of a callback or blocking subscription. After the
+// subscription was closed it returns the remaining events once, then false.
+func (s *Subscription) take() ([]Event, bool) {
+	for {
+		s.sendMu.Lock()
+		queued := s.queue
+		s.queue = nil
+		s.sendMu.Unlock()
+		if len(queued) > 0 {
+			return queued, true
+		}
+
+		select {
+		case <-s.wake:
+		case <-s.done:
+			s.sendMu.Lock()
+			queued = s.queue
+			s.queue = nil
+			s.sendMu.Unlock()
+			return queued, len(queued) > 0
+		}
+	}
+}
+
+func (s *Subscription) drop() {
+	s.dropped.Add(1)
+	s.d.dropped.Add(1)
+}
+
+// Close removes the subscription. When it was the last handler that needed
+// a servernotifyregister scope, the scope is unregistered on the server.
+// Close on a nil Subscription is a no-op.
+func (s *Subscription) Close() error {
+	if s == nil || !s.close() {
+		return nil
+	}
+	if s.c == nil || len(s.scopes) == 0 {
+		return nil
+	}
+	return s.c.releaseNotify(s.scopes)
+}
+
+// close ends the subscription and reports whether this call closed it.
+// Queued events can still be received before the channel reports closed.
+func (s *Subscription) close() bool {
+	closed := false
+	s.closeOnce.Do(func() {
+		closed = true
+		close(s.done)
+		s.d.remove(s)
+
+		s.sendMu.Lock()
+		stop := s.stop
+		if !s.blocking {
+			close(s.ch)
+		}
+		s.sendMu.Unlock()
+		if stop != nil {
+			stop()
+		}
+	})
+	return closed
+}
+
+// Events subscribes to notifications. Events are delivered in the order they
+// arrived; filter selects names and configures the queue. The channel is
+// closed when ctx is done or the client is closed.
+//
+//	for ev := range client.Events(ctx, ts3.EventFilter{Names: []string{ts3.EventTextMessage}}) {
+//		var msg models.TextMessage
+//		_ = ev.Decode(&msg)
+//	}
+//
+// Subscribing does not send servernotifyregister; use RegisterServerEvents
+// and friends. On a Pool the returned channel is already closed.
+func (c *Client) Events(ctx context.Context, filter EventFilter) <-chan Event {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	s := c.events.subscribe(c, filter, "", nil)
+	if c.pool != nil {
+		s.close()
+		return s.ch
+	}
+	s.sendMu.Lock()
+	select {
+	case <-s.done:
+	default:
+		s.stop = context.AfterFunc(ctx, func() { s.close() })
+	}
+	s.sendMu.Unlock()
+	return s.ch
+}
+
+// DroppedEvents returns how many events were discarded by the overflow
+// policies of all subscriptions.
+func (c *Client) DroppedEvents() uint64 {
+	return c.events.dropped.Load()
+}
+
+// Dispatcher returns the event dispatcher, e.g. to add handler middleware.
+func (c *Client) Dispatcher() *Dispatcher {
+	return c.events
+}
+
+// Subscriptions describes the open Register handlers and Events channels.
+func (c *Client) Subscriptions() []SubscriptionInfo {
+	return c.events.infos()
+}
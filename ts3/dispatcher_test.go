@@ -0,0 +1,268 @@
+package ts3
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func newEventTestClient(t *testing.T, cfg Config) *Client {
+	t.Helper()
+	conn := newMockServerConn(t, func(cmd string) []string {
+		return []string{"error id=0 msg=ok"}
+	})
+	client, err := NewClientFromConn(conn, cfg)
+	if err != nil {
+		t.Fatalf("NewClientFromConn failed: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func TestEventsDeliverInArrivalOrder(t *testing.T) {
+	client := newEventTestClient(t, Config{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := client.Events(ctx, EventFilter{Names: []string{EventClientEnterView, EventClientLeftView}})
+
+	var handled []string
+	done := make(chan struct{})
+	client.Register(EventClientLeftView, func(data string) {
+		handled = append(handled, data)
+		if len(handled) == 50 {
+			close(done)
+		}
+	})
+
+	go func() {
+		for i := 0; i < 50; i++ {
+			client.dispatchNotify(fmt.Sprintf("notifycliententerview clid=%d", i))
+			client.dispatchNotify("notifytextmessage msg=ignored")
+			client.dispatchNotify(fmt.Sprintf("notifyclientleftview clid=%d", i))
+		}
+	}()
+
+	for i := 0; i < 50; i++ {
+		for _, name := range []string{EventClientEnterView, EventClientLeftView} {
+			select {
+			case ev := <-events:
+				if ev.Name != name || ev.Data != fmt.Sprintf("clid=%d", i) || ev.Received.IsZero() {
+					t.Fatalf("unexpected event %d: %+v", i, ev)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatalf("event %s %d not delivered", name, i)
+			}
+		}
+	}
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("handler got %d events", len(handled))
+	}
+	for i, data := range handled {
+		if data != fmt.Sprintf("clid=%d", i) {
+			t.Fatalf("handler out of order at %d: %q", i, data)
+		}
+	}
+}
+
+func TestEventsOverflowPolicies(t *testing.T) {
+	client := newEventTestClient(t, Config{EventBuffer: 2})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	newest := client.Events(ctx, EventFilter{Overflow: OverflowDropNewest})
+	oldest := client.Events(ctx, EventFilter{Overflow: OverflowDropOldest})
+
+	for i := 1; i <= 4; i++ {
+		client.dispatchNotify(fmt.Sprintf("notifytextmessage msg=%d", i))
+	}
+
+	for _, tc := range []struct {
+		ch   <-chan Event
+		want []string
+	}{
+		{newest, []string{"msg=1", "msg=2"}},
+		{oldest, []string{"msg=3", "msg=4"}},
+	} {
+		for _, want := range tc.want {
+			if ev := <-tc.ch; ev.Data != want {
+				t.Fatalf("unexpected event: got=%q want=%q", ev.Data, want)
+			}
+		}
+	}
+	if got := client.DroppedEvents(); got != 4 {
+		t.Fatalf("unexpected dropped count: %d", got)
+	}
+}
+
+func TestEventsBlockQueuesWithoutHoldingPublish(t *testing.T) {
+	client := newEventTestClient(t, Config{EventBuffer: 1})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := client.Events(ctx, EventFilter{Overflow: OverflowBlock})
+	other := client.Events(ctx, EventFilter{Buffer: 8})
+
+	published := make(chan struct{})
+	go func() {
+		for i := 1; i <= 3; i++ {
+			client.dispatchNotify(fmt.Sprintf("notifytextmessage msg=%d", i))
+		}
+		close(published)
+	}()
+	select {
+	case <-published:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("publish blocked on a full blocking subscription")
+	}
+	for i := 1; i <= 3; i++ {
+		select {
+		case ev := <-other:
+			if want := fmt.Sprintf("msg=%d", i); ev.Data != want {
+				t.Fatalf("unexpected event on other subscription: %q, want %q", ev.Data, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("other subscription did not get event %d", i)
+		}
+	}
+
+	// Nothing was dropped; the events arrive in order.
+	for i := 1; i <= 3; i++ {
+		select {
+		case ev := <-events:
+			if want := fmt.Sprintf("msg=%d", i); ev.Data != want {
+				t.Fatalf("unexpected event: %q, want %q", ev.Data, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("event %d not delivered", i)
+		}
+	}
+	if got := client.DroppedEvents(); got != 0 {
+		t.Fatalf("unexpected dropped count: %d", got)
+	}
+
+	client.dispatchNotify("notifytextmessage msg=4")
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	// Events that fit into the channel are still delivered before it closes.
+	if ev, ok := <-events; !ok || ev.Data != "msg=4" {
+		t.Fatalf("unexpected event: %+v ok=%v", ev, ok)
+	}
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Fatalf("expected closed channel")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("channel not closed after cancel")
+	}
+}
+
+func TestBlockingEventsConsumerCanExec(t *testing.T) {
+	conn := newMockServerConn(t, func(cmd string) []string {
+		if cmd == "burst" {
+			lines := make([]string, 0, 21)
+			for i := 0; i < 20; i++ {
+				lines = append(lines, fmt.Sprintf("notifytextmessage msg=%d", i))
+			}
+			return append(lines, "error id=0 msg=ok")
+		}
+		return []string{"version=3.13.7", "error id=0 msg=ok"}
+	})
+	client, err := NewClientFromConn(conn, Config{EventBuffer: 1, EventOverflow: OverflowBlock, FloodLimit: FloodLimit{Disabled: true}})
+	if err != nil {
+		t.Fatalf("NewClientFromConn failed: %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	events := client.Events(ctx, EventFilter{Names: []string{EventTextMessage}})
+	burst := make(chan error, 1)
+	go func() {
+		_, err := client.Exec(ctx, "burst")
+		burst <- err
+	}()
+	for i := 0; i < 20; i++ {
+		select {
+		case <-events:
+			if _, err := client.Exec(ctx, "version"); err != nil {
+				t.Fatalf("Exec in consumer failed: %v", err)
+			}
+		case <-ctx.Done():
+			t.Fatalf("consumer stuck after %d events", i)
+		}
+	}
+	if err := <-burst; err != nil {
+		t.Fatalf("burst failed: %v", err)
+	}
+}
+
+func TestHandlerCanExecWhileEventsQueue(t *testing.T) {
+	conn := newMockServerConn(t, func(cmd string) []string {
+		if cmd == "burst" {
+			lines := make([]string, 0, 21)
+			for i := 0; i < 20; i++ {
+				lines = append(lines, fmt.Sprintf("notifytextmessage msg=%d", i))
+			}
+			return append(lines, "error id=0 msg=ok")
+		}
+		return []string{"version=3.13.7", "error id=0 msg=ok"}
+	})
+	client, err := NewClientFromConn(conn, Config{EventBuffer: 1, EventOverflow: OverflowBlock, FloodLimit: FloodLimit{Disabled: true}})
+	if err != nil {
+		t.Fatalf("NewClientFromConn failed: %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	// The handler replies to every event while more events are arriving.
+	done := make(chan error, 20)
+	client.Register(EventTextMessage, func(string) {
+		_, err := client.Exec(ctx, "version")
+		done <- err
+	})
+	if _, err := client.Exec(ctx, "burst"); err != nil {
+		t.Fatalf("burst failed: %v", err)
+	}
+	for i := 0; i < 20; i++ {
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatalf("Exec in handler failed: %v", err)
+			}
+		case <-ctx.Done():
+			t.Fatalf("handler stuck after %d events", i)
+		}
+	}
+	if got := client.DroppedEvents(); got != 0 {
+		t.Fatalf("handler events were dropped: %d", got)
+	}
+}
+
+func TestUnregisterStopsHandlers(t *testing.T) {
+	client := newEventTestClient(t, Config{})
+
+	calls := make(chan string, 4)
+	client.Register(EventTextMessage, func(data string) { calls <- data })
+	client.dispatchNotify("notifytextmessage msg=1")
+	select {
+	case <-calls:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("handler not called")
+	}
+
+	client.Unregister(EventTextMessage)
+	client.dispatchNotify("notifytextmessage msg=2")
+	select {
+	case data := <-calls:
+		t.Fatalf("unregistered handler called with %q", data)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,198 @@
+package ts3
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Encoder converts tagged Go structs into ServerQuery command parameters.
+//
+// It is the counterpart of Decoder and uses the same `ts3:"..."` tags:
+//
+//	Name    string  `ts3:"channel_name"`                // always sent
+//	Topic   string  `ts3:"channel_topic,omitempty"`     // skipped when empty
+//	Max     int     `ts3:"channel_maxclients,positive"` // skipped unless > 0
+//	Codec   *int    `ts3:"channel_codec"`               // skipped when nil
+//	Perm    bool    `ts3:"channel_flag_permanent"`      // sent as 1/0
+//	IDs     []int   `ts3:"cid"`                         // cid=1|cid=2
+//	UID     bool    `ts3:"-uid"`                        // option flag, sent when true
+//	Note    string  `ts3:"-"`                           // never sent
+//
+// time.Time and time.Duration fields use the same unit options as the
+// Decoder. Values are escaped. Anonymous struct fields are flattened.
+type Encoder struct{}
+
+// NewEncoder creates an Encoder instance.
+func NewEncoder() *Encoder {
+	return &Encoder{}
+}
+
+// Encode returns the parameters of v, which must be a struct or a pointer to
+// a struct, joined by spaces.
+func (e *Encoder) Encode(v interface{}) (string, error) {
+	params, err := e.encodeParams(v)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(params, " "), nil
+}
+
+func (e *Encoder) encodeParams(v interface{}) ([]string, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, errors.New("ts3: Encode requires a non-nil struct")
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, errors.New("ts3: Encode source must be a struct")
+	}
+
+	var params []string
+	if err := e.encodeStruct(rv, &params); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+func (e *Encoder) encodeStruct(source reflect.Value, params *[]string) error {
+	t := source.Type()
+	for i := 0; i < source.NumField(); i++ {
+		field := source.Field(i)
+		structField := t.Field(i)
+
+		tag := structField.Tag.Get("ts3")
+		if structField.Anonymous && tag == "" {
+			if field.Kind() == reflect.Ptr {
+				if field.IsNil() {
+					continue
+				}
+				field = field.Elem()
+			}
+			if field.Kind() == reflect.Struct {
+				if err := e.encodeStruct(field, params); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if !structField.IsExported() {
+			continue
+		}
+
+		name, opts := parseTag(tag)
+		if name == "" || name == "-" {
+			continue
+		}
+
+		if strings.HasPrefix(name, "-") {
+			if field.Kind() != reflect.Bool {
+				return fmt.Errorf("ts3: field %s (%s): option flags must be bool", structField.Name, name)
+			}
+			if field.Bool() {
+				*params = append(*params, name)
+			}
+			continue
+		}
+
+		if field.Kind() == reflect.Ptr {
+			if field.IsNil() {
+				continue
+			}
+			field = field.Elem()
+		} else if opts.has("omitempty") && field.IsZero() {
+			continue
+		}
+		if opts.has("positive") && !isPositive(field) {
+			continue
+		}
+
+		param, err := encodeField(name, field, opts)
+		if err != nil {
+			return fmt.Errorf("ts3: field %s (%s): %w", structField.Name, name, err)
+		}
+		if param != "" {
+			*params = append(*params, param)
+		}
+	}
+	return nil
+}
+
+// isPositive reports whether a number is greater than zero. Other kinds
+// count as positive.
+func isPositive(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() > 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint() > 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() > 0
+	}
+	return true
+}
+
+// encodeField returns key=value, or key=v1|key=v2 for slices.
+func encodeField(name string, field reflect.Value, opts tagOptions) (string, error) {
+	if field.Kind() != reflect.Slice {
+		value, err := encodeValue(field, opts)
+		if err != nil {
+			return "", err
+		}
+		return name + "=" + value, nil
+	}
+
+	parts := make([]string, 0, field.Len())
+	for i := 0; i < field.Len(); i++ {
+		value, err := encodeValue(field.Index(i), opts)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, name+"="+value)
+	}
+	return strings.Join(parts, "|"), nil
+}
+
+func encodeValue(v reflect.Value, opts tagOptions) (string, error) {
+	switch v.Type() {
+	case timeType:
+		t := v.Interface().(time.Time)
+		switch {
+		case t.IsZero():
+			return "0", nil
+		case opts.has("ms"):
+			return strconv.FormatInt(t.UnixMilli(), 10), nil
+		default:
+			return strconv.FormatInt(t.Unix(), 10), nil
+		}
+	case durationType:
+		d := time.Duration(v.Int())
+		if opts.has("ms") {
+			return strconv.FormatInt(d.Milliseconds(), 10), nil
+		}
+		return strconv.FormatInt(int64(d/time.Second), 10), nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return Escape(v.String()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Bool:
+		if v.Bool() {
+			return "1", nil
+		}
+		return "0", nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	default:
+		return "", fmt.Errorf("unsupported type %s", v.Type())
+	}
+}
@@ -0,0 +1,98 @@
+package ts3
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEncoderEncode(t *testing.T) {
+	type base struct {
+		ServerID int `ts3:"sid"`
+	}
+	type options struct {
+		base
+		Name      string  `ts3:"channel_name"`
+		Topic     string  `ts3:"channel_topic,omitempty"`
+		Codec     *int    `ts3:"channel_codec"`
+		MaxUsers  *int    `ts3:"channel_maxclients"`
+		Permanent bool    `ts3:"channel_flag_permanent"`
+		Default   bool    `ts3:"channel_flag_default,omitempty"`
+		Volume    float64 `ts3:"volume,omitempty"`
+		ClientIDs []int   `ts3:"clid"`
+		UID       bool    `ts3:"-uid"`
+		Away      bool    `ts3:"-away"`
+		Ignored   string
+		Skipped   int `ts3:"-"`
+	}
+
+	codec := 0
+	got, err := NewEncoder().Encode(&options{
+		base:      base{ServerID: 1},
+		Name:      "Music Room",
+		Codec:     &codec,
+		Permanent: true,
+		Volume:    1.5,
+		ClientIDs: []int{3, 4},
+		UID:       true,
+		Ignored:   "x",
+		Skipped:   7,
+	})
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+
+	want := "sid=1 channel_name=Music\\sRoom channel_codec=0 channel_flag_permanent=1 volume=1.5 clid=3|clid=4 -uid"
+	if got != want {
+		t.Fatalf("unexpected params:\ngot:  %q\nwant: %q", got, want)
+	}
+}
+
+func TestEncoderPositiveOption(t *testing.T) {
+	type options struct {
+		MaxClients int     `ts3:"channel_maxclients,positive"`
+		Delay      int64   `ts3:"channel_delete_delay,positive"`
+		Volume     float64 `ts3:"volume,positive"`
+		Order      *uint   `ts3:"channel_order,positive"`
+	}
+
+	order := uint(0)
+	got, err := NewEncoder().Encode(options{MaxClients: -1, Delay: 30, Volume: -0.5, Order: &order})
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	if got != "channel_delete_delay=30" {
+		t.Fatalf("unexpected params: %q", got)
+	}
+}
+
+func TestEncoderEncodeTime(t *testing.T) {
+	got, err := NewEncoder().Encode(struct {
+		Start time.Time     `ts3:"start"`
+		End   time.Time     `ts3:"end,omitempty"`
+		Delay time.Duration `ts3:"delay,s"`
+		Idle  time.Duration `ts3:"idle,ms"`
+	}{
+		Start: time.Unix(1700000000, 0),
+		Delay: 90 * time.Second,
+		Idle:  1500 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	if want := "start=1700000000 delay=90 idle=1500"; got != want {
+		t.Fatalf("unexpected params:\ngot:  %q\nwant: %q", got, want)
+	}
+}
+
+func TestEncoderRejectsInvalidInput(t *testing.T) {
+	if _, err := NewEncoder().Encode(42); err == nil {
+		t.Fatalf("expected error for non-struct source")
+	}
+
+	type badFlag struct {
+		Flag int `ts3:"-flag"`
+	}
+	if _, err := NewEncoder().Encode(badFlag{Flag: 1}); err == nil {
+		t.Fatalf("expected error for non-bool flag")
+	}
+}
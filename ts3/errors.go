@@ -1,15 +1,22 @@
 package ts3
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
 
 // Error represents an error returned by the TS3 ServerQuery API.
 type Error struct {
-	ID  int    `ts3:"id"`
-	Msg string `ts3:"msg"`
+	ID       int    `ts3:"id"`
+	Msg      string `ts3:"msg"`
+	ExtraMsg string `ts3:"extra_msg"`
 }
 
 // Error implements the error interface.
 func (e *Error) Error() string {
+	if e.ExtraMsg != "" {
+		return fmt.Sprintf("ts3 error %d: %s (%s)", e.ID, e.Msg, e.ExtraMsg)
+	}
 	return fmt.Sprintf("ts3 error %d: %s", e.ID, e.Msg)
 }
 
@@ -27,6 +34,7 @@ const (
 	ErrDatabaseEmptyResult = 1281
 	ErrPermissions         = 2568
 	ErrNicknameInUse       = 513
+	ErrClientFlooding      = 524
 	ErrFloodBan            = 3329
 )
 
@@ -37,3 +45,60 @@ func NewError(id int, msg string) error {
 	}
 	return &Error{ID: id, Msg: msg}
 }
+
+var (
+	errClientClosed = errors.New("ts3: client closed")
+	errConnClosed   = errors.New("ts3: connection closed")
+	errReconnecting = errors.New("ts3: connection lost, reconnecting")
+)
+
+// ErrProtocolSyncLost is returned when a cancelled command did not receive
+// its reply within the drain grace period. Later replies can no longer be
+// matched to their commands, so the connection is closed.
+var ErrProtocolSyncLost = errors.New("ts3: protocol sync lost")
+
+// ConnectionError reports that a command failed because the underlying
+// transport was lost.
+//
+// Retryable is true when the client restores the session in the background,
+// so the same command can be sent again once it is back.
+type ConnectionError struct {
+	Err       error
+	Retryable bool
+}
+
+// Error implements the error interface.
+func (e *ConnectionError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying transport error.
+func (e *ConnectionError) Unwrap() error {
+	return e.Err
+}
+
+// IsRetryable reports whether err is a transport failure that may succeed
+// when retried after the client reconnected.
+func IsRetryable(err error) bool {
+	var connErr *ConnectionError
+	return errors.As(err, &connErr) && connErr.Retryable
+}
+
+// HostKeyError reports that the SSH server presented a host key that did not
+// pass verification, e.g. a known_hosts mismatch or a wrong pinned
+// fingerprint. It is distinct from authentication failures.
+type HostKeyError struct {
+	Host        string
+	Fingerprint string
+	Err         error
+}
+
+// Error implements the error interface.
+func (e *HostKeyError) Error() string {
+	return fmt.Sprintf("ts3: ssh host key verification failed for %s (%s): %v", e.Host, e.Fingerprint, e.Err)
+}
+
+// Unwrap returns the verification error, e.g. *knownhosts.KeyError.
+func (e *HostKeyError) Unwrap() error {
+	return e.Err
+}
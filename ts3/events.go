@@ -3,11 +3,31 @@ package ts3
 import (
 	"context"
 	"errors"
-	"strconv"
 	"strings"
+	"time"
+
+	"github.com/jkesh/ts3-go/v2/ts3/models"
+)
+
+var (
+	errWebQueryNotifyUnsupported = errors.New("ts3: event notifications are not supported in webquery mode")
+	errNilHandler                = errors.New("ts3: nil event handler")
+	errEmptyEventName            = errors.New("ts3: event name is required")
 )
 
-var errWebQueryNotifyUnsupported = errors.New("ts3: event notifications are not supported in webquery mode")
+// Notification event names.
+const (
+	EventClientEnterView = "notifycliententerview"
+	EventClientLeftView  = "notifyclientleftview"
+	EventClientMoved     = "notifyclientmoved"
+	EventTextMessage     = "notifytextmessage"
+	EventChannelCreated  = "notifychannelcreated"
+	EventChannelEdited   = "notifychanneledited"
+	EventChannelDeleted  = "notifychanneldeleted"
+	EventChannelMoved    = "notifychannelmoved"
+	EventServerEdited    = "notifyserveredited"
+	EventTokenUsed       = "notifytokenused"
+)
 
 // Register registers a raw notification handler by notify event name.
 //
@@ -15,116 +35,218 @@ var errWebQueryNotifyUnsupported = errors.New("ts3: event notifications are not
 //   - notifytextmessage
 //   - notifycliententerview
 //   - notifyclientleftview
-func (c *Client) Register(eventName string, callback func(string)) {
-	if strings.TrimSpace(eventName) == "" || callback == nil {
-		return
+//
+// Each handler is a subscription with its own unbounded queue: it is called
+// from one goroutine, one event at a time, in arrival order, behind the
+// middleware added with Dispatcher.Use. A slow handler only delays its own
+// events and may run commands; the queue grows until it catches up.
+//
+// Register does not send servernotifyregister. Close the returned
+// Subscription to remove just this handler. It returns nil when eventName is
+// empty or callback is nil.
+func (c *Client) Register(eventName string, callback func(string)) *Subscription {
+	eventName = strings.TrimSpace(eventName)
+	if eventName == "" || callback == nil {
+		return nil
 	}
+	return c.register(eventName, callback, nil)
+}
 
-	c.notifyMu.Lock()
-	c.notifications[eventName] = append(c.notifications[eventName], callback)
-	c.notifyMu.Unlock()
+func (c *Client) register(eventName string, callback func(string), scopes []NotifyScope) *Subscription {
+	s := c.events.subscribe(c, EventFilter{Names: []string{eventName}}, eventName, scopes)
+	c.spawn(func() {
+		final := func(ev Event) { callback(ev.Data) }
+		var handler Handler
+		var gen uint64
+		for {
+			events, ok := s.take()
+			if !ok {
+				return
+			}
+			for _, ev := range events {
+				if handler == nil || gen != c.events.chainGen.Load() {
+					handler, gen = c.events.chain(final)
+				}
+				handler(ev)
+			}
+		}
+	})
+	return s
 }
 
-// Unregister removes all handlers bound to one event name.
+// Unregister removes all handlers bound to one event name, like closing each
+// of their subscriptions. Events subscriptions are not affected.
 func (c *Client) Unregister(eventName string) {
-	c.notifyMu.Lock()
-	delete(c.notifications, eventName)
-	c.notifyMu.Unlock()
+	for _, s := range c.events.handlers(strings.TrimSpace(eventName)) {
+		if err := s.Close(); err != nil {
+			c.logf("ts3: unregister %s: %v", eventName, err)
+		}
+	}
 }
 
-// dispatchNotify parses a raw notify line and dispatches it to handlers.
+// dispatchNotify parses a raw notify line and queues it for the
+// subscriptions.
 func (c *Client) dispatchNotify(rawLine string) {
-	parts := strings.SplitN(rawLine, " ", 2)
-	eventName := parts[0]
-	eventData := ""
-	if len(parts) == 2 {
-		eventData = parts[1]
-	}
-
-	c.notifyMu.RLock()
-	handlers := append([]func(string){}, c.notifications[eventName]...)
-	c.notifyMu.RUnlock()
+	now := time.Now()
+	c.recorder.notify(now, rawLine)
+	name, data, _ := strings.Cut(rawLine, " ")
+	c.events.publish(Event{Name: name, Data: data, Received: now})
+}
 
-	for _, h := range handlers {
-		go h(eventData)
+// registerScoped registers callback after acquiring the servernotifyregister
+// scopes that deliver eventName. Closing the subscription releases them.
+func (c *Client) registerScoped(ctx context.Context, eventName string, callback func(string)) (*Subscription, error) {
+	if err := c.notifyUnsupported(); err != nil {
+		return nil, err
+	}
+	if err := c.eventUnsupported(eventName); err != nil {
+		return nil, err
+	}
+	if callback == nil {
+		return nil, errNilHandler
 	}
-}
 
-// RegisterServerEvents subscribes to server-level client enter/leave/move events.
-func (c *Client) RegisterServerEvents(ctx context.Context) error {
-	if c.isWebQuery() {
-		return errWebQueryNotifyUnsupported
+	scopes := eventScopes[eventName]
+	if err := c.acquireNotify(ctx, scopes); err != nil {
+		return nil, err
 	}
-	_, err := c.Exec(ctx, "servernotifyregister event=server")
-	return err
+	return c.register(eventName, callback, scopes), nil
 }
 
-// RegisterChannelEvents subscribes to channel-level events.
-//
-// channelID is optional:
-//   - 0: current/default behavior
-//   - >0: explicit channel id
-func (c *Client) RegisterChannelEvents(ctx context.Context, channelID int) error {
-	if c.isWebQuery() {
-		return errWebQueryNotifyUnsupported
-	}
-	cmd := "servernotifyregister event=channel"
-	if channelID > 0 {
-		cmd += " id=" + strconv.Itoa(channelID)
+// handleEvent registers a handler that decodes every row of eventName into a
+// T. Rows that cannot be decoded are logged and skipped.
+func handleEvent[T any](ctx context.Context, c *Client, eventName string, handler func(T)) (*Subscription, error) {
+	if handler == nil {
+		return nil, errNilHandler
 	}
-	_, err := c.Exec(ctx, cmd)
-	return err
+	return c.registerScoped(ctx, eventName, func(data string) {
+		for _, row := range notifyRows(data) {
+			var event T
+			if err := NewDecoder().Decode(row, &event); err != nil {
+				c.logf("ts3: decode %s: %v", eventName, err)
+				continue
+			}
+			handler(event)
+		}
+	})
 }
 
-// RegisterTextEvents subscribes to private, channel and server text message events.
-func (c *Client) RegisterTextEvents(ctx context.Context) error {
-	if c.isWebQuery() {
-		return errWebQueryNotifyUnsupported
-	}
-	if _, err := c.Exec(ctx, "servernotifyregister event=textprivate"); err != nil {
-		return err
-	}
-	if _, err := c.Exec(ctx, "servernotifyregister event=textserver"); err != nil {
-		return err
+// notifyRows splits the data of a multi-row notification. The server sends
+// shared properties such as cfid or reasonid only in the first row, so later
+// rows inherit the keys they do not set.
+func notifyRows(data string) []string {
+	rows := strings.Split(data, "|")
+	if len(rows) == 1 {
+		return rows
 	}
-	if _, err := c.Exec(ctx, "servernotifyregister event=textchannel"); err != nil {
-		return err
+
+	shared := strings.Fields(rows[0])
+	for i := 1; i < len(rows); i++ {
+		fields := strings.Fields(rows[i])
+		have := make(map[string]bool, len(fields))
+		for _, field := range fields {
+			key, _, _ := strings.Cut(field, "=")
+			have[key] = true
+		}
+		for _, field := range shared {
+			if key, _, _ := strings.Cut(field, "="); !have[key] {
+				fields = append(fields, field)
+			}
+		}
+		rows[i] = strings.Join(fields, " ")
 	}
-	return nil
+	return rows
 }
 
-// UnregisterNotify unsubscribes current query client from notifications.
-func (c *Client) UnregisterNotify(ctx context.Context) error {
-	if c.isWebQuery() {
-		return errWebQueryNotifyUnsupported
+// OnEvent registers a raw handler for eventName, e.g. EventTextMessage, and
+// sends the servernotifyregister commands the event needs. Unlike Register,
+// closing the returned Subscription also releases those registrations once no
+// other handler needs them.
+func (c *Client) OnEvent(ctx context.Context, eventName string, handler func(string)) (*Subscription, error) {
+	eventName = strings.TrimSpace(eventName)
+	if eventName == "" {
+		return nil, errEmptyEventName
 	}
-	_, err := c.Exec(ctx, "servernotifyunregister")
-	return err
+	return c.registerScoped(ctx, eventName, handler)
 }
 
-// OnClientEnter registers a handler for "notifycliententerview".
+// OnClientEnter registers a raw handler for "notifycliententerview".
+//
+// Deprecated: The handler can only be removed with Unregister, which removes
+// every handler of the event. Use OnClientEnterView or OnEvent, which return a
+// Subscription for this handler alone.
 func (c *Client) OnClientEnter(ctx context.Context, handler func(string)) error {
-	if err := c.RegisterServerEvents(ctx); err != nil {
-		return err
-	}
-	c.Register("notifycliententerview", handler)
-	return nil
+	_, err := c.registerScoped(ctx, EventClientEnterView, handler)
+	return err
 }
 
-// OnClientLeave registers a handler for "notifyclientleftview".
+// OnClientLeave registers a raw handler for "notifyclientleftview".
+//
+// Deprecated: The handler can only be removed with Unregister, which removes
+// every handler of the event. Use OnClientLeftView or OnEvent, which return a
+// Subscription for this handler alone.
 func (c *Client) OnClientLeave(ctx context.Context, handler func(string)) error {
-	if err := c.RegisterServerEvents(ctx); err != nil {
-		return err
-	}
-	c.Register("notifyclientleftview", handler)
-	return nil
+	_, err := c.registerScoped(ctx, EventClientLeftView, handler)
+	return err
 }
 
-// OnTextMessage registers a handler for "notifytextmessage".
+// OnTextMessage registers a raw handler for "notifytextmessage".
+//
+// Deprecated: The handler can only be removed with Unregister, which removes
+// every handler of the event. Use OnText or OnEvent, which return a
+// Subscription for this handler alone.
 func (c *Client) OnTextMessage(ctx context.Context, handler func(string)) error {
-	if err := c.RegisterTextEvents(ctx); err != nil {
-		return err
-	}
-	c.Register("notifytextmessage", handler)
-	return nil
+	_, err := c.registerScoped(ctx, EventTextMessage, handler)
+	return err
+}
+
+// OnClientEnterView registers a typed handler for "notifycliententerview".
+func (c *Client) OnClientEnterView(ctx context.Context, handler func(models.ClientEnterView)) (*Subscription, error) {
+	return handleEvent(ctx, c, EventClientEnterView, handler)
+}
+
+// OnClientLeftView registers a typed handler for "notifyclientleftview".
+func (c *Client) OnClientLeftView(ctx context.Context, handler func(models.ClientLeftView)) (*Subscription, error) {
+	return handleEvent(ctx, c, EventClientLeftView, handler)
+}
+
+// OnClientMoved registers a typed handler for "notifyclientmoved". It
+// registers "event=channel" without a channel id.
+func (c *Client) OnClientMoved(ctx context.Context, handler func(models.ClientMoved)) (*Subscription, error) {
+	return handleEvent(ctx, c, EventClientMoved, handler)
+}
+
+// OnText registers a typed handler for "notifytextmessage".
+func (c *Client) OnText(ctx context.Context, handler func(models.TextMessage)) (*Subscription, error) {
+	return handleEvent(ctx, c, EventTextMessage, handler)
+}
+
+// OnChannelCreated registers a typed handler for "notifychannelcreated".
+func (c *Client) OnChannelCreated(ctx context.Context, handler func(models.ChannelCreated)) (*Subscription, error) {
+	return handleEvent(ctx, c, EventChannelCreated, handler)
+}
+
+// OnChannelEdited registers a typed handler for "notifychanneledited".
+func (c *Client) OnChannelEdited(ctx context.Context, handler func(models.ChannelEdited)) (*Subscription, error) {
+	return handleEvent(ctx, c, EventChannelEdited, handler)
+}
+
+// OnChannelDeleted registers a typed handler for "notifychanneldeleted".
+func (c *Client) OnChannelDeleted(ctx context.Context, handler func(models.ChannelDeleted)) (*Subscription, error) {
+	return handleEvent(ctx, c, EventChannelDeleted, handler)
+}
+
+// OnChannelMoved registers a typed handler for "notifychannelmoved".
+func (c *Client) OnChannelMoved(ctx context.Context, handler func(models.ChannelMoved)) (*Subscription, error) {
+	return handleEvent(ctx, c, EventChannelMoved, handler)
+}
+
+// OnServerEdited registers a typed handler for "notifyserveredited".
+func (c *Client) OnServerEdited(ctx context.Context, handler func(models.ServerEdited)) (*Subscription, error) {
+	return handleEvent(ctx, c, EventServerEdited, handler)
+}
+
+// OnTokenUsed registers a typed handler for "notifytokenused".
+func (c *Client) OnTokenUsed(ctx context.Context, handler func(models.TokenUsed)) (*Subscription, error) {
+	return handleEvent(ctx, c, EventTokenUsed, handler)
 }
@@ -0,0 +1,235 @@
+package ts3
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jkesh/ts3-go/v2/ts3/models"
+)
+
+func TestTypedEventsDecodeEveryRow(t *testing.T) {
+	cmdCh := make(chan string, 8)
+	conn := newMockServerConn(t, func(cmd string) []string {
+		cmdCh <- cmd
+		return []string{"error id=0 msg=ok"}
+	})
+
+	client, err := NewClientFromConn(conn, Config{})
+	if err != nil {
+		t.Fatalf("NewClientFromConn failed: %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	left := make(chan models.ClientLeftView, 4)
+	if _, err := client.OnClientLeftView(ctx, func(ev models.ClientLeftView) { left <- ev }); err != nil {
+		t.Fatalf("OnClientLeftView failed: %v", err)
+	}
+	moved := make(chan models.ClientMoved, 1)
+	if _, err := client.OnClientMoved(ctx, func(ev models.ClientMoved) { moved <- ev }); err != nil {
+		t.Fatalf("OnClientMoved failed: %v", err)
+	}
+	text := make(chan models.TextMessage, 1)
+	if _, err := client.OnText(ctx, func(ev models.TextMessage) { text <- ev }); err != nil {
+		t.Fatalf("OnText failed: %v", err)
+	}
+
+	var cmds []string
+	for len(cmdCh) > 0 {
+		cmds = append(cmds, <-cmdCh)
+	}
+	joined := strings.Join(cmds, "\n")
+	for _, want := range []string{"event=server", "event=channel", "event=textprivate"} {
+		if !strings.Contains(joined, want) {
+			t.Fatalf("missing registration %q in %q", want, joined)
+		}
+	}
+
+	client.dispatchNotify(`notifyclientleftview cfid=1 ctid=0 reasonid=8 reasonmsg=leaving clid=5|clid=6`)
+	for _, wantID := range []int{5, 6} {
+		select {
+		case ev := <-left:
+			if ev.ClientID != wantID || ev.FromChannelID != 1 || ev.ReasonID != 8 || ev.ReasonMessage != "leaving" {
+				t.Fatalf("unexpected left event: %+v", ev)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("left event for clid=%d not delivered", wantID)
+		}
+	}
+
+	client.dispatchNotify(`notifyclientmoved ctid=3 reasonid=1 invokerid=2 invokername=Admin invokeruid=abc= clid=7`)
+	select {
+	case ev := <-moved:
+		if ev.ClientID != 7 || ev.ToChannelID != 3 || ev.InvokerName != "Admin" || ev.InvokerUID != "abc=" {
+			t.Fatalf("unexpected moved event: %+v", ev)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("moved event not delivered")
+	}
+
+	client.dispatchNotify(`notifytextmessage targetmode=1 msg=hello\sworld target=9 invokerid=4 invokername=Alice`)
+	select {
+	case ev := <-text:
+		if ev.Message != "hello world" || ev.TargetMode != TextTargetClient || ev.Target != 9 || ev.InvokerID != 4 {
+			t.Fatalf("unexpected text event: %+v", ev)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("text event not delivered")
+	}
+}
+
+func TestNotifyRowsInheritSharedKeys(t *testing.T) {
+	got := notifyRows("cfid=1 reasonid=8 clid=5|clid=6 reasonid=3")
+	want := []string{"cfid=1 reasonid=8 clid=5", "clid=6 reasonid=3 cfid=1"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected rows: got=%q want=%q", got, want)
+	}
+}
+
+func TestSubscriptionCloseReleasesNotifyScopes(t *testing.T) {
+	cmdCh := make(chan string, 16)
+	conn := newMockServerConn(t, func(cmd string) []string {
+		cmdCh <- cmd
+		return []string{"error id=0 msg=ok"}
+	})
+
+	client, err := NewClientFromConn(conn, Config{})
+	if err != nil {
+		t.Fatalf("NewClientFromConn failed: %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	sent := func() []string {
+		var cmds []string
+		for len(cmdCh) > 0 {
+			cmds = append(cmds, <-cmdCh)
+		}
+		return cmds
+	}
+
+	first, err := client.OnClientMoved(ctx, func(models.ClientMoved) {})
+	if err != nil {
+		t.Fatalf("OnClientMoved failed: %v", err)
+	}
+	second, err := client.OnChannelCreated(ctx, func(models.ChannelCreated) {})
+	if err != nil {
+		t.Fatalf("OnChannelCreated failed: %v", err)
+	}
+	if err := client.RegisterTokenEvents(ctx); err != nil {
+		t.Fatalf("RegisterTokenEvents failed: %v", err)
+	}
+	want := []string{scopeChannel.command(), scopeTokenUsed.command()}
+	if got := sent(); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected registrations: got=%q want=%q", got, want)
+	}
+
+	infos := client.Subscriptions()
+	if len(infos) != 2 || !infos[0].Handler || infos[0].Names[0] != EventClientMoved || infos[0].Scopes[0] != scopeChannel {
+		t.Fatalf("unexpected subscriptions: %+v", infos)
+	}
+
+	if err := first.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if got := sent(); len(got) != 0 {
+		t.Fatalf("scope still in use, unexpected commands: %q", got)
+	}
+
+	if err := second.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	want = []string{"servernotifyunregister", scopeTokenUsed.command()}
+	if got := sent(); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected commands: got=%q want=%q", got, want)
+	}
+	if infos := client.Subscriptions(); len(infos) != 0 {
+		t.Fatalf("unexpected subscriptions: %+v", infos)
+	}
+	if err := second.Close(); err != nil {
+		t.Fatalf("second Close failed: %v", err)
+	}
+}
+
+func TestRegisterCloseRemovesOnlyOneHandler(t *testing.T) {
+	client := newEventTestClient(t, Config{})
+
+	a := make(chan string, 2)
+	b := make(chan string, 2)
+	subA := client.Register(EventTextMessage, func(data string) { a <- data })
+	client.Register(EventTextMessage, func(data string) { b <- data })
+
+	if err := subA.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	client.dispatchNotify("notifytextmessage msg=hi")
+
+	select {
+	case <-b:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("remaining handler not called")
+	}
+	select {
+	case data := <-a:
+		t.Fatalf("closed handler called with %q", data)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestOnEventCloseRemovesOnlyOneHandler(t *testing.T) {
+	conn := newMockServerConn(t, func(cmd string) []string {
+		return []string{"error id=0 msg=ok"}
+	})
+	client, err := NewClientFromConn(conn, Config{})
+	if err != nil {
+		t.Fatalf("NewClientFromConn failed: %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if _, err := client.OnEvent(ctx, " ", func(string) {}); err == nil {
+		t.Fatalf("expected empty event name to fail")
+	}
+
+	a := make(chan string, 2)
+	b := make(chan string, 2)
+	subA, err := client.OnEvent(ctx, EventTextMessage, func(data string) { a <- data })
+	if err != nil {
+		t.Fatalf("OnEvent failed: %v", err)
+	}
+	if _, err := client.OnEvent(ctx, EventTextMessage, func(data string) { b <- data }); err != nil {
+		t.Fatalf("OnEvent failed: %v", err)
+	}
+	if err := subA.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	regs := client.NotifyRegistrations()
+	if len(regs) != 3 {
+		t.Fatalf("unexpected registrations: %+v", regs)
+	}
+	for _, reg := range regs {
+		if reg.Handlers != 1 {
+			t.Fatalf("unexpected registration after Close: %+v", reg)
+		}
+	}
+
+	client.dispatchNotify("notifytextmessage msg=hi")
+	select {
+	case <-b:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("remaining handler not called")
+	}
+	select {
+	case data := <-a:
+		t.Fatalf("closed handler called with %q", data)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,243 @@
+package ts3
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// TeamSpeak's documented ServerQuery flood defaults
+// (serverinstance_serverquery_flood_commands / _flood_time).
+const (
+	defaultFloodCommands   = 10
+	defaultFloodWindow     = 3 * time.Second
+	defaultFloodMaxRetries = 3
+)
+
+var floodRetryPattern = regexp.MustCompile(`(?i)(\d+)\s*(?:seconds?|secs?|s)\b`)
+
+// FloodLimit configures the client-side flood protection limiter.
+//
+// The limiter is a token bucket that refills Commands tokens per Window and
+// holds at most Burst tokens. The zero value uses TeamSpeak's defaults of 10
+// commands per 3 seconds. Query clients on the server's whitelist can set
+// Disabled.
+type FloodLimit struct {
+	Disabled bool
+	Commands int
+	Window   time.Duration
+	Burst    int
+	// MaxRetries is how often a command is resent after the server answered
+	// with a flood error. 0 uses 3; a negative value returns the flood error
+	// right away.
+	MaxRetries int
+}
+
+// FloodStats reports how the flood limiter delayed commands.
+type FloodStats struct {
+	// Commands is the number of commands that passed the limiter.
+	Commands uint64
+	// Waits is the number of commands that had to wait.
+	Waits uint64
+	// WaitTime is the total time callers waited.
+	WaitTime time.Duration
+	// MaxWait is the longest single wait.
+	MaxWait time.Duration
+	// FloodErrors counts flood errors returned by the server.
+	FloodErrors uint64
+}
+
+// floodLimiter is a token bucket that can be paused after a flood error.
+type floodLimiter struct {
+	mu          sync.Mutex
+	rate        float64 // tokens per second
+	burst       float64
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+	maxRetries  int
+	stats       FloodStats
+}
+
+func newFloodLimiter(cfg FloodLimit) *floodLimiter {
+	if cfg.Disabled {
+		return nil
+	}
+	if cfg.Commands <= 0 {
+		cfg.Commands = defaultFloodCommands
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = defaultFloodWindow
+	}
+	if cfg.Burst <= 0 {
+		cfg.Burst = cfg.Commands
+	}
+	switch {
+	case cfg.MaxRetries == 0:
+		cfg.MaxRetries = defaultFloodMaxRetries
+	case cfg.MaxRetries < 0:
+		cfg.MaxRetries = 0
+	}
+
+	return &floodLimiter{
+		rate:       float64(cfg.Commands) / cfg.Window.Seconds(),
+		burst:      float64(cfg.Burst),
+		tokens:     float64(cfg.Burst),
+		last:       time.Now(),
+		maxRetries: cfg.MaxRetries,
+	}
+}
+
+// wait blocks until a command may be sent.
+func (l *floodLimiter) wait(ctx context.Context) error {
+	start := time.Now()
+	for {
+		d := l.reserve()
+		if d <= 0 {
+			break
+		}
+
+		timer := time.NewTimer(d)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			l.recordWait(time.Since(start))
+			return ctx.Err()
+		}
+	}
+
+	l.recordWait(time.Since(start))
+	return nil
+}
+
+// reserve takes a token and returns 0, or returns how long to wait.
+func (l *floodLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Before(l.pausedUntil) {
+		return l.pausedUntil.Sub(now)
+	}
+
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+
+	if l.tokens >= 1 {
+		l.tokens--
+		l.stats.Commands++
+		return 0
+	}
+	return time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+}
+
+func (l *floodLimiter) recordWait(d time.Duration) {
+	if d < time.Millisecond {
+		return
+	}
+	l.mu.Lock()
+	l.stats.Waits++
+	l.stats.WaitTime += d
+	if d > l.stats.MaxWait {
+		l.stats.MaxWait = d
+	}
+	l.mu.Unlock()
+}
+
+// pause stops all commands for d and empties the bucket.
+func (l *floodLimiter) pause(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.stats.FloodErrors++
+	until := time.Now().Add(d)
+	if until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+	l.tokens = 0
+	l.last = l.pausedUntil
+}
+
+func (l *floodLimiter) snapshot() FloodStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.stats
+}
+
+// floodRetryAfter reports whether err is a flood error and how long the
+// server asked to wait.
+func floodRetryAfter(err error) (time.Duration, bool) {
+	var ts3Err *Error
+	if !errors.As(err, &ts3Err) {
+		return 0, false
+	}
+	if ts3Err.ID != ErrClientFlooding && ts3Err.ID != ErrFloodBan {
+		return 0, false
+	}
+
+	for _, text := range []string{ts3Err.ExtraMsg, ts3Err.Msg} {
+		if m := floodRetryPattern.FindStringSubmatch(text); m != nil {
+			if n, err := strconv.Atoi(m[1]); err == nil && n > 0 {
+				return time.Duration(n) * time.Second, true
+			}
+		}
+	}
+	return defaultFloodWindow, true
+}
+
+// execLimited runs a raw command through the flood limiter and resends it
+// when the server reports flooding. The caller must hold c.mu.
+func (c *Client) execLimited(ctx context.Context, cmd string, onRow rowFunc) error {
+	limiter := c.limiter.Load()
+	if limiter == nil {
+		return c.execRaw(ctx, cmd, onRow)
+	}
+
+	// A flood error arrives without rows. A command whose rows were already
+	// delivered is never resent.
+	delivered := false
+	counted := func(row string) bool {
+		delivered = true
+		return onRow == nil || onRow(row)
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := limiter.wait(ctx); err != nil {
+			return err
+		}
+
+		err := c.execRaw(ctx, cmd, counted)
+		retryAfter, flooded := floodRetryAfter(err)
+		if !flooded || delivered {
+			return err
+		}
+
+		limiter.pause(retryAfter)
+		if attempt >= limiter.maxRetries {
+			return err
+		}
+		c.logf("ts3: flood protection triggered, pausing commands for %s", retryAfter)
+	}
+}
+
+// SetFloodLimit replaces the flood limiter of a raw/SSH client.
+func (c *Client) SetFloodLimit(cfg FloodLimit) {
+	c.limiter.Store(newFloodLimiter(cfg))
+}
+
+// FloodStats returns flood limiter metrics. It is empty when the limiter is
+// disabled.
+func (c *Client) FloodStats() FloodStats {
+	limiter := c.limiter.Load()
+	if limiter == nil {
+		return FloodStats{}
+	}
+	return limiter.snapshot()
+}
@@ -0,0 +1,117 @@
+package ts3
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFloodLimiterPacesCommands(t *testing.T) {
+	conn := newMockServerConn(t, func(cmd string) []string {
+		return []string{"error id=0 msg=ok"}
+	})
+
+	client, err := NewClientFromConn(conn, Config{
+		FloodLimit: FloodLimit{Commands: 10, Window: time.Second, Burst: 1},
+	})
+	if err != nil {
+		t.Fatalf("NewClientFromConn failed: %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if _, err := client.Exec(ctx, "version"); err != nil {
+			t.Fatalf("Exec failed: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 250*time.Millisecond {
+		t.Fatalf("commands were not paced: %v", elapsed)
+	}
+
+	stats := client.FloodStats()
+	if stats.Commands != 4 || stats.Waits == 0 || stats.WaitTime <= 0 {
+		t.Fatalf("unexpected flood stats: %+v", stats)
+	}
+}
+
+func TestFloodErrorPausesAndRetries(t *testing.T) {
+	var calls atomic.Int32
+	conn := newMockServerConn(t, func(cmd string) []string {
+		if cmd == "clientlist" && calls.Add(1) == 1 {
+			return []string{"error id=524 msg=client\\sis\\sflooding extra_msg=please\\swait\\s1\\sseconds"}
+		}
+		return []string{"clid=1 client_nickname=Alice", "error id=0 msg=ok"}
+	})
+
+	client, err := NewClientFromConn(conn, Config{})
+	if err != nil {
+		t.Fatalf("NewClientFromConn failed: %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	clients, err := client.ClientList(ctx)
+	if err != nil {
+		t.Fatalf("ClientList failed: %v", err)
+	}
+	if len(clients) != 1 {
+		t.Fatalf("unexpected clients: %+v", clients)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Fatalf("client did not honour retry hint: %v", elapsed)
+	}
+
+	stats := client.FloodStats()
+	if stats.FloodErrors != 1 || stats.MaxWait < 900*time.Millisecond {
+		t.Fatalf("unexpected flood stats: %+v", stats)
+	}
+}
+
+func TestFloodNegativeMaxRetriesFailsFast(t *testing.T) {
+	var calls atomic.Int32
+	conn := newMockServerConn(t, func(cmd string) []string {
+		calls.Add(1)
+		return []string{"error id=524 msg=client\\sis\\sflooding extra_msg=please\\swait\\s1\\sseconds"}
+	})
+
+	client, err := NewClientFromConn(conn, Config{FloodLimit: FloodLimit{MaxRetries: -1}})
+	if err != nil {
+		t.Fatalf("NewClientFromConn failed: %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	_, err = client.Exec(ctx, "clientlist")
+	var qerr *Error
+	if !errors.As(err, &qerr) || !qerr.Is(ErrClientFlooding) {
+		t.Fatalf("expected flood error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("flood error was retried: %v", elapsed)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("expected 1 attempt, got %d", n)
+	}
+}
+
+func TestFloodRetryAfter(t *testing.T) {
+	d, ok := floodRetryAfter(&Error{ID: ErrFloodBan, Msg: "banned", ExtraMsg: "you may retry in 600 seconds"})
+	if !ok || d != 600*time.Second {
+		t.Fatalf("unexpected retry hint: %v %v", d, ok)
+	}
+	if _, ok := floodRetryAfter(&Error{ID: ErrPermissions}); ok {
+		t.Fatalf("permission error must not be treated as flood error")
+	}
+}
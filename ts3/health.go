@@ -0,0 +1,178 @@
+package ts3
+
+import (
+	"context"
+	"time"
+)
+
+const defaultProbeTimeout = 5 * time.Second
+
+// State is the transport health of a client.
+type State int
+
+const (
+	// StateConnecting means the transport is being (re)established.
+	StateConnecting State = iota
+	// StateReady means the transport is healthy.
+	StateReady
+	// StateDegraded means nothing was received within Config.ReadIdleTimeout
+	// and a probe command is outstanding.
+	StateDegraded
+	// StateClosed means the transport is gone and will not come back.
+	StateClosed
+)
+
+// String returns the state name.
+func (s State) String() string {
+	switch s {
+	case StateConnecting:
+		return "connecting"
+	case StateReady:
+		return "ready"
+	case StateDegraded:
+		return "degraded"
+	case StateClosed:
+		return "closed"
+	default:
+		return "unknown"
+	}
+}
+
+// State returns the current transport health.
+func (c *Client) State() State {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	return c.state
+}
+
+// WatchState returns a channel that receives the current state and every
+// later change. A slow reader only misses intermediate states; the latest
+// one is always delivered. The channel is closed after StateClosed or when
+// ctx is done; a nil ctx never ends.
+func (c *Client) WatchState(ctx context.Context) <-chan State {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ch := make(chan State, 1)
+
+	c.stateMu.Lock()
+	ch <- c.state
+	if c.state == StateClosed {
+		c.stateMu.Unlock()
+		close(ch)
+		return ch
+	}
+	if c.stateWatchers == nil {
+		c.stateWatchers = make(map[chan State]func() bool)
+	}
+	c.stateWatchers[ch] = context.AfterFunc(ctx, func() {
+		c.stateMu.Lock()
+		if _, ok := c.stateWatchers[ch]; ok {
+			delete(c.stateWatchers, ch)
+			close(ch)
+		}
+		c.stateMu.Unlock()
+	})
+	c.stateMu.Unlock()
+	return ch
+}
+
+// setState records a state change and notifies watchers. StateClosed is
+// final.
+func (c *Client) setState(s State) {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+
+	if c.state == s || c.state == StateClosed {
+		return
+	}
+	c.state = s
+
+	for ch, stop := range c.stateWatchers {
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- s
+		if s == StateClosed {
+			stop()
+			close(ch)
+		}
+	}
+	if s == StateClosed {
+		c.stateWatchers = nil
+	}
+}
+
+// touch records that data arrived on the transport.
+func (c *Client) touch() {
+	c.lastRecv.Store(time.Now().UnixNano())
+}
+
+func (c *Client) sinceLastRecv() time.Duration {
+	return time.Since(time.Unix(0, c.lastRecv.Load()))
+}
+
+// healthLoop probes the server when nothing was received for window and
+// closes the transport when the probe is not answered either. It does not
+// probe while a command is in flight, because the probe would wait behind
+// it.
+func (c *Client) healthLoop(window, probeTimeout time.Duration) {
+	ticker := time.NewTicker(window / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-c.quit:
+			return
+		}
+
+		if c.State() != StateReady || c.inFlight.Load() > 0 || c.sinceLastRecv() < window {
+			continue
+		}
+		if !c.probe(probeTimeout) {
+			return
+		}
+	}
+}
+
+// probe sends a command and waits for any data from the server. It reports
+// false when the client was closed meanwhile.
+func (c *Client) probe(timeout time.Duration) bool {
+	c.setState(StateDegraded)
+	sent := c.lastRecv.Load()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	done := make(chan struct{})
+	c.spawn(func() {
+		defer close(done)
+		probeCmd := "whoami"
+		if c.isWebQuery() {
+			probeCmd = "version"
+		}
+		_, _ = c.Exec(ctx, probeCmd)
+	})
+
+	// Exec may be queued behind a hanging command, therefore the timeout is
+	// enforced here as well.
+	select {
+	case <-done:
+	case <-ctx.Done():
+	case <-c.quit:
+		return false
+	}
+
+	if c.lastRecv.Load() != sent {
+		c.setState(StateReady)
+		return true
+	}
+
+	c.logf("ts3: no data received for %s, closing dead connection", c.sinceLastRecv().Round(time.Millisecond))
+	if err := c.closeConn(); err != nil {
+		c.logf("ts3: close dead connection: %v", err)
+	}
+	return true
+}
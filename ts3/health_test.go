@@ -0,0 +1,130 @@
+package ts3
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHealthProbeDetectsDeadPeer(t *testing.T) {
+	var silent atomic.Bool
+	var probes atomic.Int32
+	conn := newMockServerConn(t, func(cmd string) []string {
+		if silent.Load() {
+			return nil
+		}
+		if cmd == "whoami" {
+			probes.Add(1)
+		}
+		return []string{"error id=0 msg=ok"}
+	})
+
+	client, err := NewClientFromConn(conn, Config{
+		ReadIdleTimeout: 100 * time.Millisecond,
+		ProbeTimeout:    100 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewClientFromConn failed: %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	states := client.WatchState(ctx)
+	if s := <-states; s != StateReady {
+		t.Fatalf("unexpected initial state: %v", s)
+	}
+
+	// An answered probe keeps the client ready.
+	deadline := time.Now().Add(2 * time.Second)
+	for probes.Load() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("no probe was sent")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	for s := range states {
+		if s == StateReady {
+			break
+		}
+	}
+
+	silent.Store(true)
+	var seen []State
+	degraded := false
+	for s := range states {
+		seen = append(seen, s)
+		degraded = degraded || s == StateDegraded
+		if s == StateClosed {
+			break
+		}
+	}
+	if !degraded || seen[len(seen)-1] != StateClosed {
+		t.Fatalf("unexpected state transitions: %v", seen)
+	}
+	if client.State() != StateClosed {
+		t.Fatalf("unexpected final state: %v", client.State())
+	}
+}
+
+func TestHealthDoesNotProbeDuringSlowCommand(t *testing.T) {
+	var probes atomic.Int32
+	conn := newMockServerConn(t, func(cmd string) []string {
+		switch cmd {
+		case "serversnapshotcreate":
+			time.Sleep(600 * time.Millisecond)
+			return []string{"data=snapshot", "error id=0 msg=ok"}
+		case "whoami":
+			probes.Add(1)
+		}
+		return []string{"error id=0 msg=ok"}
+	})
+
+	client, err := NewClientFromConn(conn, Config{
+		ReadIdleTimeout: 100 * time.Millisecond,
+		ProbeTimeout:    100 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewClientFromConn failed: %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	states := client.WatchState(ctx)
+	<-states
+
+	if _, err := client.Exec(ctx, "serversnapshotcreate"); err != nil {
+		t.Fatalf("slow command failed: %v", err)
+	}
+	select {
+	case s := <-states:
+		t.Fatalf("state changed during slow command: %v", s)
+	default:
+	}
+	if n := probes.Load(); n != 0 {
+		t.Fatalf("probe sent during slow command: %d", n)
+	}
+}
+
+func TestWatchStateNilContext(t *testing.T) {
+	conn := newMockServerConn(t, func(cmd string) []string {
+		return []string{"error id=0 msg=ok"}
+	})
+	client, err := NewClientFromConn(conn, Config{})
+	if err != nil {
+		t.Fatalf("NewClientFromConn failed: %v", err)
+	}
+
+	states := client.WatchState(nil)
+	if s := <-states; s != StateReady {
+		t.Fatalf("unexpected initial state: %v", s)
+	}
+	client.Close()
+	for s := range states {
+		if s != StateClosed {
+			t.Fatalf("unexpected state: %v", s)
+		}
+	}
+}
@@ -5,7 +5,7 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/jkesh/ts3-go/ts3/models"
+	"github.com/jkesh/ts3-go/v2/ts3/models"
 )
 
 const (
@@ -19,126 +19,63 @@ const (
 	KickReasonServer  = 5
 )
 
-func withOptions(base string, options []string) string {
-	if len(options) == 0 {
-		return base
+// withOptions adds raw option tokens such as "-uid" or "start=0" to cmd.
+func withOptions(cmd *Command, options []string) *Command {
+	for _, opt := range options {
+		cmd.addRaw(opt)
 	}
-	return base + " " + strings.Join(options, " ")
+	return cmd
 }
 
 // Version returns server query version/build/platform information.
 func (c *Client) Version(ctx context.Context) (*models.VersionInfo, error) {
-	resp, err := c.Exec(ctx, "version")
-	if err != nil {
-		return nil, err
-	}
-
-	var info models.VersionInfo
-	if err := NewDecoder().Decode(resp, &info); err != nil {
-		return nil, err
-	}
-	return &info, nil
+	return QueryOne[models.VersionInfo](ctx, c, "version")
 }
 
 // HostInfo returns instance-level host information.
 func (c *Client) HostInfo(ctx context.Context) (*models.HostInfo, error) {
-	resp, err := c.Exec(ctx, "hostinfo")
-	if err != nil {
-		return nil, err
-	}
-
-	var info models.HostInfo
-	if err := NewDecoder().Decode(resp, &info); err != nil {
-		return nil, err
-	}
-	return &info, nil
+	return QueryOne[models.HostInfo](ctx, c, "hostinfo")
 }
 
 // WhoAmI returns information about the current query session.
 func (c *Client) WhoAmI(ctx context.Context) (*models.WhoAmI, error) {
-	resp, err := c.Exec(ctx, "whoami")
-	if err != nil {
-		return nil, err
-	}
-
-	var me models.WhoAmI
-	if err := NewDecoder().Decode(resp, &me); err != nil {
-		return nil, err
-	}
-	return &me, nil
+	return QueryOne[models.WhoAmI](ctx, c, "whoami")
 }
 
 // ServerList returns virtual servers on this instance.
 func (c *Client) ServerList(ctx context.Context, options ...string) ([]models.VirtualServer, error) {
-	resp, err := c.Exec(ctx, withOptions("serverlist", options))
-	if err != nil {
-		return nil, err
-	}
-
-	var servers []models.VirtualServer
-	if err := NewDecoder().Decode(resp, &servers); err != nil {
-		return nil, err
-	}
-	return servers, nil
+	return QueryAll[models.VirtualServer](ctx, c, withOptions(NewCommand("serverlist"), options))
 }
 
 // ServerInfo returns details for the currently selected virtual server.
 func (c *Client) ServerInfo(ctx context.Context) (*models.ServerInfo, error) {
-	resp, err := c.Exec(ctx, "serverinfo")
-	if err != nil {
-		return nil, err
-	}
-
-	var info models.ServerInfo
-	if err := NewDecoder().Decode(resp, &info); err != nil {
-		return nil, err
-	}
-	return &info, nil
+	return QueryOne[models.ServerInfo](ctx, c, "serverinfo")
 }
 
 // ServerGroupList returns server groups in the selected virtual server.
 func (c *Client) ServerGroupList(ctx context.Context) ([]models.ServerGroup, error) {
-	resp, err := c.Exec(ctx, "servergrouplist")
-	if err != nil {
-		return nil, err
-	}
-
-	var groups []models.ServerGroup
-	if err := NewDecoder().Decode(resp, &groups); err != nil {
-		return nil, err
-	}
-	return groups, nil
+	return QueryAll[models.ServerGroup](ctx, c, "servergrouplist")
 }
 
 // ClientList returns online clients.
 //
-// options can include official command switches, such as "-uid", "-away",
-// "-voice", "-groups", "-times", "-country" and so on.
+// options can include official command switches, such as OptUID, OptAway,
+// OptVoice, OptGroups, OptTimes, OptCountry and so on.
 func (c *Client) ClientList(ctx context.Context, options ...string) ([]models.OnlineClient, error) {
-	resp, err := c.Exec(ctx, withOptions("clientlist", options))
-	if err != nil {
-		return nil, err
-	}
-
-	var clients []models.OnlineClient
-	if err := NewDecoder().Decode(resp, &clients); err != nil {
-		return nil, err
-	}
-	return clients, nil
+	return QueryAll[models.OnlineClient](ctx, c, withOptions(NewCommand("clientlist"), options))
 }
 
 // ClientInfo returns details for one connected client.
 func (c *Client) ClientInfo(ctx context.Context, clientID int) (*models.ClientInfo, error) {
-	resp, err := c.Exec(ctx, fmt.Sprintf("clientinfo clid=%d", clientID))
+	info, err := QueryOne[models.ClientInfo](ctx, c, fmt.Sprintf("clientinfo clid=%d", clientID))
 	if err != nil {
 		return nil, err
 	}
-
-	var info models.ClientInfo
-	if err := NewDecoder().Decode(resp, &info); err != nil {
-		return nil, err
+	// clientinfo does not echo the client id.
+	if info.ID == 0 {
+		info.ID = clientID
 	}
-	return &info, nil
+	return info, nil
 }
 
 // ClientDBList returns clients from the database.
@@ -147,47 +84,22 @@ func (c *Client) ClientInfo(ctx context.Context, clientID int) (*models.ClientIn
 //   - start: first row offset
 //   - duration: max rows to return
 func (c *Client) ClientDBList(ctx context.Context, start, duration int, options ...string) ([]models.DBClient, error) {
-	cmd := fmt.Sprintf("clientdblist start=%d duration=%d", start, duration)
-	cmd = withOptions(cmd, options)
-
-	resp, err := c.Exec(ctx, cmd)
-	if err != nil {
-		return nil, err
-	}
-
-	var clients []models.DBClient
-	if err := NewDecoder().Decode(resp, &clients); err != nil {
-		return nil, err
-	}
-	return clients, nil
+	cmd := NewCommand("clientdblist").Param("start", start).Param("duration", duration)
+	return QueryAll[models.DBClient](ctx, c, withOptions(cmd, options))
 }
 
 // ClientDBFind finds client database entries by nickname pattern.
 func (c *Client) ClientDBFind(ctx context.Context, pattern string, options ...string) ([]models.DBClient, error) {
-	cmd := withOptions(fmt.Sprintf("clientdbfind pattern=%s", Escape(pattern)), options)
-	resp, err := c.Exec(ctx, cmd)
-	if err != nil {
-		return nil, err
-	}
-
-	var clients []models.DBClient
-	if err := NewDecoder().Decode(resp, &clients); err != nil {
-		return nil, err
-	}
-	return clients, nil
+	cmd := withOptions(NewCommand("clientdbfind").Param("pattern", pattern), options)
+	return QueryAll[models.DBClient](ctx, c, cmd)
 }
 
 // ClientGetDBIDFromUID resolves a client database id by unique identifier.
 func (c *Client) ClientGetDBIDFromUID(ctx context.Context, uid string) (int, error) {
-	resp, err := c.Exec(ctx, fmt.Sprintf("clientgetdbidfromuid cluid=%s", Escape(uid)))
-	if err != nil {
-		return 0, err
-	}
-
-	var out struct {
+	out, err := QueryOne[struct {
 		DBID int `ts3:"cldbid"`
-	}
-	if err := NewDecoder().Decode(resp, &out); err != nil {
+	}](ctx, c, fmt.Sprintf("clientgetdbidfromuid cluid=%s", Escape(uid)))
+	if err != nil {
 		return 0, err
 	}
 	return out.DBID, nil
@@ -195,15 +107,10 @@ func (c *Client) ClientGetDBIDFromUID(ctx context.Context, uid string) (int, err
 
 // ClientGetNameFromDBID resolves a nickname by client database id.
 func (c *Client) ClientGetNameFromDBID(ctx context.Context, dbid int) (string, error) {
-	resp, err := c.Exec(ctx, fmt.Sprintf("clientgetnamefromdbid cldbid=%d", dbid))
-	if err != nil {
-		return "", err
-	}
-
-	var out struct {
+	out, err := QueryOne[struct {
 		Name string `ts3:"name"`
-	}
-	if err := NewDecoder().Decode(resp, &out); err != nil {
+	}](ctx, c, fmt.Sprintf("clientgetnamefromdbid cldbid=%d", dbid))
+	if err != nil {
 		return "", err
 	}
 	return out.Name, nil
@@ -211,16 +118,11 @@ func (c *Client) ClientGetNameFromDBID(ctx context.Context, dbid int) (string, e
 
 // ClientGetNameFromUID resolves a nickname by unique identifier.
 func (c *Client) ClientGetNameFromUID(ctx context.Context, uid string) (string, error) {
-	resp, err := c.Exec(ctx, fmt.Sprintf("clientgetnamefromuid cluid=%s", Escape(uid)))
-	if err != nil {
-		return "", err
-	}
-
-	var out struct {
+	out, err := QueryOne[struct {
 		Name2 string `ts3:"name"`
 		Name  string `ts3:"clname"`
-	}
-	if err := NewDecoder().Decode(resp, &out); err != nil {
+	}](ctx, c, fmt.Sprintf("clientgetnamefromuid cluid=%s", Escape(uid)))
+	if err != nil {
 		return "", err
 	}
 	if out.Name == "" {
@@ -231,49 +133,31 @@ func (c *Client) ClientGetNameFromUID(ctx context.Context, uid string) (string,
 
 // ChannelList returns channels in the selected virtual server.
 func (c *Client) ChannelList(ctx context.Context, options ...string) ([]models.Channel, error) {
-	resp, err := c.Exec(ctx, withOptions("channellist", options))
-	if err != nil {
-		return nil, err
-	}
-
-	var channels []models.Channel
-	if err := NewDecoder().Decode(resp, &channels); err != nil {
-		return nil, err
-	}
-	return channels, nil
+	return QueryAll[models.Channel](ctx, c, withOptions(NewCommand("channellist"), options))
 }
 
 // ChannelInfo returns details for a channel id.
 func (c *Client) ChannelInfo(ctx context.Context, channelID int) (*models.Channel, error) {
-	resp, err := c.Exec(ctx, fmt.Sprintf("channelinfo cid=%d", channelID))
-	if err != nil {
-		return nil, err
-	}
-
-	var ch models.Channel
-	if err := NewDecoder().Decode(resp, &ch); err != nil {
-		return nil, err
-	}
-	return &ch, nil
+	return QueryOne[models.Channel](ctx, c, fmt.Sprintf("channelinfo cid=%d", channelID))
 }
 
 // ChannelCreateOptions defines optional arguments for ChannelCreate.
 type ChannelCreateOptions struct {
-	Name              string
-	Topic             string
-	Description       string
-	Password          string
-	Codec             int
-	CodecQuality      int
-	MaxClients        int
-	MaxFamilyClients  int
-	NeededTalkPower   int
-	ParentID          int
-	Order             int
-	IsPermanent       bool
-	IsSemiPermanent   bool
-	IsDefault         bool
-	DeleteDelaySecond int
+	Name              string `ts3:"channel_name"`
+	Topic             string `ts3:"channel_topic,omitempty"`
+	Description       string `ts3:"channel_description,omitempty"`
+	Password          string `ts3:"channel_password,omitempty"`
+	Codec             int    `ts3:"channel_codec,omitempty"`
+	CodecQuality      int    `ts3:"channel_codec_quality,omitempty"`
+	MaxClients        int    `ts3:"channel_maxclients,omitempty"`
+	MaxFamilyClients  int    `ts3:"channel_maxfamilyclients,omitempty"`
+	NeededTalkPower   int    `ts3:"channel_needed_talk_power,omitempty"`
+	ParentID          int    `ts3:"cpid,omitempty"`
+	Order             int    `ts3:"channel_order,omitempty"`
+	IsPermanent       bool   `ts3:"channel_flag_permanent,omitempty"`
+	IsSemiPermanent   bool   `ts3:"channel_flag_semi_permanent,omitempty"`
+	IsDefault         bool   `ts3:"channel_flag_default,omitempty"`
+	DeleteDelaySecond int    `ts3:"channel_delete_delay,positive"`
 }
 
 // ChannelCreate creates a channel and returns the new channel id.
@@ -282,61 +166,15 @@ func (c *Client) ChannelCreate(ctx context.Context, opt ChannelCreateOptions) (i
 		return 0, fmt.Errorf("ts3: channel name is required")
 	}
 
-	parts := []string{
-		fmt.Sprintf("channel_name=%s", Escape(opt.Name)),
-	}
-	if opt.Topic != "" {
-		parts = append(parts, fmt.Sprintf("channel_topic=%s", Escape(opt.Topic)))
-	}
-	if opt.Description != "" {
-		parts = append(parts, fmt.Sprintf("channel_description=%s", Escape(opt.Description)))
-	}
-	if opt.Password != "" {
-		parts = append(parts, fmt.Sprintf("channel_password=%s", Escape(opt.Password)))
-	}
-	if opt.Codec != 0 {
-		parts = append(parts, fmt.Sprintf("channel_codec=%d", opt.Codec))
-	}
-	if opt.CodecQuality != 0 {
-		parts = append(parts, fmt.Sprintf("channel_codec_quality=%d", opt.CodecQuality))
-	}
-	if opt.MaxClients != 0 {
-		parts = append(parts, fmt.Sprintf("channel_maxclients=%d", opt.MaxClients))
-	}
-	if opt.MaxFamilyClients != 0 {
-		parts = append(parts, fmt.Sprintf("channel_maxfamilyclients=%d", opt.MaxFamilyClients))
-	}
-	if opt.NeededTalkPower != 0 {
-		parts = append(parts, fmt.Sprintf("channel_needed_talk_power=%d", opt.NeededTalkPower))
-	}
-	if opt.ParentID != 0 {
-		parts = append(parts, fmt.Sprintf("cpid=%d", opt.ParentID))
-	}
-	if opt.Order != 0 {
-		parts = append(parts, fmt.Sprintf("channel_order=%d", opt.Order))
-	}
-	if opt.IsPermanent {
-		parts = append(parts, "channel_flag_permanent=1")
-	}
-	if opt.IsSemiPermanent {
-		parts = append(parts, "channel_flag_semi_permanent=1")
-	}
-	if opt.IsDefault {
-		parts = append(parts, "channel_flag_default=1")
-	}
-	if opt.DeleteDelaySecond > 0 {
-		parts = append(parts, fmt.Sprintf("channel_delete_delay=%d", opt.DeleteDelaySecond))
-	}
-
-	resp, err := c.Exec(ctx, "channelcreate "+strings.Join(parts, " "))
+	params, err := NewEncoder().Encode(opt)
 	if err != nil {
 		return 0, err
 	}
 
-	var out struct {
+	out, err := QueryOne[struct {
 		ChannelID int `ts3:"cid"`
-	}
-	if err := NewDecoder().Decode(resp, &out); err != nil {
+	}](ctx, c, "channelcreate "+params)
+	if err != nil {
 		return 0, err
 	}
 	return out.ChannelID, nil
@@ -422,15 +260,10 @@ func (c *Client) KickFromServer(ctx context.Context, clientID int, reason string
 // timeInSeconds=0 means permanent ban.
 func (c *Client) BanClient(ctx context.Context, clientID int, timeInSeconds int64, reason string) (int, error) {
 	cmd := fmt.Sprintf("banclient clid=%d time=%d banreason=%s", clientID, timeInSeconds, Escape(reason))
-	resp, err := c.Exec(ctx, cmd)
-	if err != nil {
-		return 0, err
-	}
-
-	var out struct {
+	out, err := QueryOne[struct {
 		BanID int `ts3:"banid"`
-	}
-	if err := NewDecoder().Decode(resp, &out); err != nil {
+	}](ctx, c, cmd)
+	if err != nil {
 		return 0, err
 	}
 	return out.BanID, nil
@@ -471,15 +304,10 @@ func (c *Client) TokenAdd(ctx context.Context, tokenType, id1, id2 int, descript
 		Escape(description),
 	)
 
-	resp, err := c.Exec(ctx, cmd)
-	if err != nil {
-		return "", err
-	}
-
-	var out struct {
+	out, err := QueryOne[struct {
 		Token string `ts3:"token"`
-	}
-	if err := NewDecoder().Decode(resp, &out); err != nil {
+	}](ctx, c, cmd)
+	if err != nil {
 		return "", err
 	}
 	return out.Token, nil
@@ -487,16 +315,7 @@ func (c *Client) TokenAdd(ctx context.Context, tokenType, id1, id2 int, descript
 
 // TokenList returns all currently unused privilege keys.
 func (c *Client) TokenList(ctx context.Context) ([]models.Token, error) {
-	resp, err := c.Exec(ctx, "tokenlist")
-	if err != nil {
-		return nil, err
-	}
-
-	var tokens []models.Token
-	if err := NewDecoder().Decode(resp, &tokens); err != nil {
-		return nil, err
-	}
-	return tokens, nil
+	return QueryAll[models.Token](ctx, c, "tokenlist")
 }
 
 // TokenDelete deletes one privilege key.
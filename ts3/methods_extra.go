@@ -6,141 +6,77 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/jkesh/ts3-go/ts3/models"
+	"github.com/jkesh/ts3-go/v2/ts3/models"
 )
 
 // ServerEditOptions contains optional fields for "serveredit".
 type ServerEditOptions struct {
-	Name                        string
-	WelcomeMessage              string
-	Password                    string
-	MaxClients                  int
-	HostMessage                 string
-	HostMessageMode             int
-	DefaultServerGroup          int
-	DefaultChannelGroup         int
-	NeededIdentitySecurityLevel int
-	MinClientVersion            int64
+	Name                        string `ts3:"virtualserver_name,omitempty"`
+	WelcomeMessage              string `ts3:"virtualserver_welcomemessage,omitempty"`
+	Password                    string `ts3:"virtualserver_password,omitempty"`
+	MaxClients                  int    `ts3:"virtualserver_maxclients,positive"`
+	HostMessage                 string `ts3:"virtualserver_hostmessage,omitempty"`
+	HostMessageMode             int    `ts3:"virtualserver_hostmessage_mode,positive"`
+	DefaultServerGroup          int    `ts3:"virtualserver_default_server_group,positive"`
+	DefaultChannelGroup         int    `ts3:"virtualserver_default_channel_group,positive"`
+	NeededIdentitySecurityLevel int    `ts3:"virtualserver_needed_identity_security_level,positive"`
+	MinClientVersion            int64  `ts3:"virtualserver_min_client_version,positive"`
 }
 
 // ServerEdit updates settings of the currently selected virtual server.
 func (c *Client) ServerEdit(ctx context.Context, opt ServerEditOptions) error {
-	parts := make([]string, 0, 10)
-	if opt.Name != "" {
-		parts = append(parts, "virtualserver_name="+Escape(opt.Name))
-	}
-	if opt.WelcomeMessage != "" {
-		parts = append(parts, "virtualserver_welcomemessage="+Escape(opt.WelcomeMessage))
-	}
-	if opt.Password != "" {
-		parts = append(parts, "virtualserver_password="+Escape(opt.Password))
-	}
-	if opt.MaxClients > 0 {
-		parts = append(parts, "virtualserver_maxclients="+strconv.Itoa(opt.MaxClients))
-	}
-	if opt.HostMessage != "" {
-		parts = append(parts, "virtualserver_hostmessage="+Escape(opt.HostMessage))
-	}
-	if opt.HostMessageMode > 0 {
-		parts = append(parts, "virtualserver_hostmessage_mode="+strconv.Itoa(opt.HostMessageMode))
-	}
-	if opt.DefaultServerGroup > 0 {
-		parts = append(parts, "virtualserver_default_server_group="+strconv.Itoa(opt.DefaultServerGroup))
-	}
-	if opt.DefaultChannelGroup > 0 {
-		parts = append(parts, "virtualserver_default_channel_group="+strconv.Itoa(opt.DefaultChannelGroup))
-	}
-	if opt.NeededIdentitySecurityLevel > 0 {
-		parts = append(parts, "virtualserver_needed_identity_security_level="+strconv.Itoa(opt.NeededIdentitySecurityLevel))
-	}
-	if opt.MinClientVersion > 0 {
-		parts = append(parts, "virtualserver_min_client_version="+strconv.FormatInt(opt.MinClientVersion, 10))
+	params, err := NewEncoder().Encode(opt)
+	if err != nil {
+		return err
 	}
-	if len(parts) == 0 {
+	if params == "" {
 		return nil
 	}
 
-	_, err := c.Exec(ctx, "serveredit "+strings.Join(parts, " "))
+	_, err = c.Exec(ctx, "serveredit "+params)
 	return err
 }
 
 // ChannelEditOptions contains optional fields for "channeledit".
 type ChannelEditOptions struct {
-	Name                 string
-	Topic                string
-	Description          string
-	Password             string
-	Codec                int
-	CodecQuality         int
-	MaxClients           int
-	MaxFamilyClients     int
-	NeededTalkPower      int
-	NeededSubscribePower int
-	IsPermanent          bool
-	IsSemiPermanent      bool
-	IsDefault            bool
-	DeleteDelaySeconds   int
+	Name                 string `ts3:"channel_name,omitempty"`
+	Topic                string `ts3:"channel_topic,omitempty"`
+	Description          string `ts3:"channel_description,omitempty"`
+	Password             string `ts3:"channel_password,omitempty"`
+	Codec                int    `ts3:"channel_codec,omitempty"`
+	CodecQuality         int    `ts3:"channel_codec_quality,omitempty"`
+	MaxClients           int    `ts3:"channel_maxclients,positive"`
+	MaxFamilyClients     int    `ts3:"channel_maxfamilyclients,positive"`
+	NeededTalkPower      int    `ts3:"channel_needed_talk_power,positive"`
+	NeededSubscribePower int    `ts3:"channel_needed_subscribe_power,positive"`
+	IsPermanent          bool   `ts3:"channel_flag_permanent,omitempty"`
+	IsSemiPermanent      bool   `ts3:"channel_flag_semi_permanent,omitempty"`
+	IsDefault            bool   `ts3:"channel_flag_default,omitempty"`
+	DeleteDelaySeconds   int    `ts3:"channel_delete_delay,positive"`
 }
 
 // ChannelEdit updates channel properties by channel id.
 func (c *Client) ChannelEdit(ctx context.Context, channelID int, opt ChannelEditOptions) error {
-	parts := make([]string, 0, 16)
-	parts = append(parts, "cid="+strconv.Itoa(channelID))
-
-	if opt.Name != "" {
-		parts = append(parts, "channel_name="+Escape(opt.Name))
-	}
-	if opt.Topic != "" {
-		parts = append(parts, "channel_topic="+Escape(opt.Topic))
-	}
-	if opt.Description != "" {
-		parts = append(parts, "channel_description="+Escape(opt.Description))
-	}
-	if opt.Password != "" {
-		parts = append(parts, "channel_password="+Escape(opt.Password))
-	}
-	if opt.Codec != 0 {
-		parts = append(parts, "channel_codec="+strconv.Itoa(opt.Codec))
-	}
-	if opt.CodecQuality != 0 {
-		parts = append(parts, "channel_codec_quality="+strconv.Itoa(opt.CodecQuality))
-	}
-	if opt.MaxClients > 0 {
-		parts = append(parts, "channel_maxclients="+strconv.Itoa(opt.MaxClients))
-	}
-	if opt.MaxFamilyClients > 0 {
-		parts = append(parts, "channel_maxfamilyclients="+strconv.Itoa(opt.MaxFamilyClients))
-	}
-	if opt.NeededTalkPower > 0 {
-		parts = append(parts, "channel_needed_talk_power="+strconv.Itoa(opt.NeededTalkPower))
-	}
-	if opt.NeededSubscribePower > 0 {
-		parts = append(parts, "channel_needed_subscribe_power="+strconv.Itoa(opt.NeededSubscribePower))
-	}
-	if opt.IsPermanent {
-		parts = append(parts, "channel_flag_permanent=1")
-	}
-	if opt.IsSemiPermanent {
-		parts = append(parts, "channel_flag_semi_permanent=1")
-	}
-	if opt.IsDefault {
-		parts = append(parts, "channel_flag_default=1")
-	}
-	if opt.DeleteDelaySeconds > 0 {
-		parts = append(parts, "channel_delete_delay="+strconv.Itoa(opt.DeleteDelaySeconds))
+	params, err := NewEncoder().Encode(opt)
+	if err != nil {
+		return err
 	}
 
-	_, err := c.Exec(ctx, "channeledit "+strings.Join(parts, " "))
+	cmd := "channeledit cid=" + strconv.Itoa(channelID)
+	if params != "" {
+		cmd += " " + params
+	}
+	_, err = c.Exec(ctx, cmd)
 	return err
 }
 
 // ServerTempPasswordOptions holds options for "servertemppasswordadd".
 type ServerTempPasswordOptions struct {
-	Password              string
-	Description           string
-	DurationSeconds       int64
-	TargetChannelID       int
-	TargetChannelPassword string
+	Password              string `ts3:"pw"`
+	Description           string `ts3:"desc"`
+	DurationSeconds       int64  `ts3:"duration"`
+	TargetChannelID       int    `ts3:"tcid,positive"`
+	TargetChannelPassword string `ts3:"tcpw,omitempty"`
 }
 
 // ServerTempPasswordAdd creates a temporary server password.
@@ -152,34 +88,18 @@ func (c *Client) ServerTempPasswordAdd(ctx context.Context, opt ServerTempPasswo
 		return fmt.Errorf("ts3: duration must be > 0")
 	}
 
-	parts := []string{
-		"pw=" + Escape(opt.Password),
-		"desc=" + Escape(opt.Description),
-		"duration=" + strconv.FormatInt(opt.DurationSeconds, 10),
-	}
-	if opt.TargetChannelID > 0 {
-		parts = append(parts, "tcid="+strconv.Itoa(opt.TargetChannelID))
-	}
-	if opt.TargetChannelPassword != "" {
-		parts = append(parts, "tcpw="+Escape(opt.TargetChannelPassword))
+	params, err := NewEncoder().Encode(opt)
+	if err != nil {
+		return err
 	}
 
-	_, err := c.Exec(ctx, "servertemppasswordadd "+strings.Join(parts, " "))
+	_, err = c.Exec(ctx, "servertemppasswordadd "+params)
 	return err
 }
 
 // ServerTempPasswordList returns active temporary server passwords.
 func (c *Client) ServerTempPasswordList(ctx context.Context) ([]models.ServerTempPassword, error) {
-	resp, err := c.Exec(ctx, "servertemppasswordlist")
-	if err != nil {
-		return nil, err
-	}
-
-	var entries []models.ServerTempPassword
-	if err := NewDecoder().Decode(resp, &entries); err != nil {
-		return nil, err
-	}
-	return entries, nil
+	return QueryAll[models.ServerTempPassword](ctx, c, "servertemppasswordlist")
 }
 
 // ServerTempPasswordDelete deletes a temporary server password by value.
@@ -195,16 +115,7 @@ func (c *Client) QueryLoginAdd(ctx context.Context, clientDBID int, serverID int
 		cmd += fmt.Sprintf(" sid=%d", serverID)
 	}
 
-	resp, err := c.Exec(ctx, cmd)
-	if err != nil {
-		return nil, err
-	}
-
-	var out models.QueryLoginCredentials
-	if err := NewDecoder().Decode(resp, &out); err != nil {
-		return nil, err
-	}
-	return &out, nil
+	return QueryOne[models.QueryLoginCredentials](ctx, c, cmd)
 }
 
 // QueryLoginDelete deletes a query login by client database id.
@@ -215,30 +126,12 @@ func (c *Client) QueryLoginDelete(ctx context.Context, clientDBID int) error {
 
 // QueryLoginList returns all query logins for the current scope.
 func (c *Client) QueryLoginList(ctx context.Context) ([]models.QueryLogin, error) {
-	resp, err := c.Exec(ctx, "queryloginlist")
-	if err != nil {
-		return nil, err
-	}
-
-	var out []models.QueryLogin
-	if err := NewDecoder().Decode(resp, &out); err != nil {
-		return nil, err
-	}
-	return out, nil
+	return QueryAll[models.QueryLogin](ctx, c, "queryloginlist")
 }
 
 // BanList returns current ban list.
 func (c *Client) BanList(ctx context.Context) ([]models.BanEntry, error) {
-	resp, err := c.Exec(ctx, "banlist")
-	if err != nil {
-		return nil, err
-	}
-
-	var bans []models.BanEntry
-	if err := NewDecoder().Decode(resp, &bans); err != nil {
-		return nil, err
-	}
-	return bans, nil
+	return QueryAll[models.BanEntry](ctx, c, "banlist")
 }
 
 // BanDelete removes a ban by ban id.
@@ -255,16 +148,7 @@ func (c *Client) BanDeleteAll(ctx context.Context) error {
 
 // ComplainList returns complaints for a specific client DBID.
 func (c *Client) ComplainList(ctx context.Context, targetClientDBID int) ([]models.ComplainEntry, error) {
-	resp, err := c.Exec(ctx, fmt.Sprintf("complainlist tcldbid=%d", targetClientDBID))
-	if err != nil {
-		return nil, err
-	}
-
-	var entries []models.ComplainEntry
-	if err := NewDecoder().Decode(resp, &entries); err != nil {
-		return nil, err
-	}
-	return entries, nil
+	return QueryAll[models.ComplainEntry](ctx, c, fmt.Sprintf("complainlist tcldbid=%d", targetClientDBID))
 }
 
 // ComplainAdd adds a complaint against a client.
@@ -297,18 +181,18 @@ func (c *Client) ChannelSubscribe(ctx context.Context, channelIDs ...int) error
 	if len(channelIDs) == 0 {
 		return nil
 	}
-	parts := make([]string, 0, len(channelIDs))
+	ids := make([]interface{}, 0, len(channelIDs))
 	for _, cid := range channelIDs {
 		if cid <= 0 {
 			continue
 		}
-		parts = append(parts, "cid="+strconv.Itoa(cid))
+		ids = append(ids, cid)
 	}
-	if len(parts) == 0 {
+	if len(ids) == 0 {
 		return nil
 	}
 
-	_, err := c.Exec(ctx, "channelsubscribe "+strings.Join(parts, "|"))
+	_, err := c.ExecCommand(ctx, NewCommand("channelsubscribe").Group("cid", ids...))
 	return err
 }
 
@@ -317,18 +201,18 @@ func (c *Client) ChannelUnsubscribe(ctx context.Context, channelIDs ...int) erro
 	if len(channelIDs) == 0 {
 		return nil
 	}
-	parts := make([]string, 0, len(channelIDs))
+	ids := make([]interface{}, 0, len(channelIDs))
 	for _, cid := range channelIDs {
 		if cid <= 0 {
 			continue
 		}
-		parts = append(parts, "cid="+strconv.Itoa(cid))
+		ids = append(ids, cid)
 	}
-	if len(parts) == 0 {
+	if len(ids) == 0 {
 		return nil
 	}
 
-	_, err := c.Exec(ctx, "channelunsubscribe "+strings.Join(parts, "|"))
+	_, err := c.ExecCommand(ctx, NewCommand("channelunsubscribe").Group("cid", ids...))
 	return err
 }
 
@@ -355,15 +239,10 @@ func (c *Client) ServerGroupAdd(ctx context.Context, name string, groupType int)
 		cmd += " type=" + strconv.Itoa(groupType)
 	}
 
-	resp, err := c.Exec(ctx, cmd)
-	if err != nil {
-		return 0, err
-	}
-
-	var out struct {
+	out, err := QueryOne[struct {
 		GroupID int `ts3:"sgid"`
-	}
-	if err := NewDecoder().Decode(resp, &out); err != nil {
+	}](ctx, c, cmd)
+	if err != nil {
 		return 0, err
 	}
 	return out.GroupID, nil
@@ -392,15 +271,10 @@ func (c *Client) ServerGroupCopy(ctx context.Context, sourceGroupID int, newName
 		cmd += fmt.Sprintf(" type=%d", groupType)
 	}
 
-	resp, err := c.Exec(ctx, cmd)
-	if err != nil {
-		return 0, err
-	}
-
-	var out struct {
+	out, err := QueryOne[struct {
 		GroupID int `ts3:"sgid"`
-	}
-	if err := NewDecoder().Decode(resp, &out); err != nil {
+	}](ctx, c, cmd)
+	if err != nil {
 		return 0, err
 	}
 	return out.GroupID, nil
@@ -408,33 +282,15 @@ func (c *Client) ServerGroupCopy(ctx context.Context, sourceGroupID int, newName
 
 // ServerGroupClientList returns clients in a server group.
 //
-// Use options like "-names" to include resolved names/uids.
+// Use options like OptNames to include resolved names/uids.
 func (c *Client) ServerGroupClientList(ctx context.Context, sgid int, options ...string) ([]models.ServerGroupClient, error) {
-	cmd := withOptions(fmt.Sprintf("servergroupclientlist sgid=%d", sgid), options)
-	resp, err := c.Exec(ctx, cmd)
-	if err != nil {
-		return nil, err
-	}
-
-	var out []models.ServerGroupClient
-	if err := NewDecoder().Decode(resp, &out); err != nil {
-		return nil, err
-	}
-	return out, nil
+	cmd := withOptions(NewCommand("servergroupclientlist").Param("sgid", sgid), options)
+	return QueryAll[models.ServerGroupClient](ctx, c, cmd)
 }
 
 // ChannelGroupList returns channel groups.
 func (c *Client) ChannelGroupList(ctx context.Context) ([]models.ChannelGroup, error) {
-	resp, err := c.Exec(ctx, "channelgrouplist")
-	if err != nil {
-		return nil, err
-	}
-
-	var out []models.ChannelGroup
-	if err := NewDecoder().Decode(resp, &out); err != nil {
-		return nil, err
-	}
-	return out, nil
+	return QueryAll[models.ChannelGroup](ctx, c, "channelgrouplist")
 }
 
 // ChannelGroupAdd creates a channel group and returns new id.
@@ -448,15 +304,10 @@ func (c *Client) ChannelGroupAdd(ctx context.Context, name string, groupType int
 		cmd += " type=" + strconv.Itoa(groupType)
 	}
 
-	resp, err := c.Exec(ctx, cmd)
-	if err != nil {
-		return 0, err
-	}
-
-	var out struct {
+	out, err := QueryOne[struct {
 		GroupID int `ts3:"cgid"`
-	}
-	if err := NewDecoder().Decode(resp, &out); err != nil {
+	}](ctx, c, cmd)
+	if err != nil {
 		return 0, err
 	}
 	return out.GroupID, nil
@@ -480,15 +331,6 @@ func (c *Client) ChannelGroupRename(ctx context.Context, cgid int, newName strin
 
 // ChannelGroupClientList returns assignments for a channel group in one channel.
 func (c *Client) ChannelGroupClientList(ctx context.Context, cgid int, channelID int, options ...string) ([]models.ChannelGroupClient, error) {
-	cmd := withOptions(fmt.Sprintf("channelgroupclientlist cgid=%d cid=%d", cgid, channelID), options)
-	resp, err := c.Exec(ctx, cmd)
-	if err != nil {
-		return nil, err
-	}
-
-	var out []models.ChannelGroupClient
-	if err := NewDecoder().Decode(resp, &out); err != nil {
-		return nil, err
-	}
-	return out, nil
+	cmd := withOptions(NewCommand("channelgroupclientlist").Param("cgid", cgid).Param("cid", channelID), options)
+	return QueryAll[models.ChannelGroupClient](ctx, c, cmd)
 }
@@ -51,9 +51,10 @@ func TestServerEditBuildsExpectedCommand(t *testing.T) {
 	defer cancel()
 
 	err = client.ServerEdit(ctx, ServerEditOptions{
-		Name:           "My Server",
-		WelcomeMessage: "hello world",
-		MaxClients:     64,
+		Name:            "My Server",
+		WelcomeMessage:  "hello world",
+		MaxClients:      64,
+		HostMessageMode: -1, // skipped like unset values
 	})
 	if err != nil {
 		t.Fatalf("ServerEdit failed: %v", err)
@@ -72,6 +73,56 @@ func TestServerEditBuildsExpectedCommand(t *testing.T) {
 	if !strings.Contains(got, "virtualserver_maxclients=64") {
 		t.Fatalf("missing maxclients: %q", got)
 	}
+	if strings.Contains(got, "virtualserver_hostmessage_mode") {
+		t.Fatalf("negative hostmessage mode was sent: %q", got)
+	}
+}
+
+func TestServerTempPasswordAddBuildsExpectedCommand(t *testing.T) {
+	cmdCh := make(chan string, 1)
+	conn := newMockServerConn(t, func(cmd string) []string {
+		cmdCh <- cmd
+		return []string{"error id=0 msg=ok"}
+	})
+
+	client, err := NewClientFromConn(conn, Config{})
+	if err != nil {
+		t.Fatalf("NewClientFromConn failed: %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	err = client.ServerTempPasswordAdd(ctx, ServerTempPasswordOptions{
+		Password:        "secret pw",
+		Description:     "for guests",
+		DurationSeconds: 3600,
+		TargetChannelID: 5,
+	})
+	if err != nil {
+		t.Fatalf("ServerTempPasswordAdd failed: %v", err)
+	}
+
+	want := "servertemppasswordadd pw=secret\\spw desc=for\\sguests duration=3600 tcid=5"
+	if got := <-cmdCh; got != want {
+		t.Fatalf("unexpected command:\ngot:  %q\nwant: %q", got, want)
+	}
+
+	// Channel ids below 1 are not sent, as before the Encoder.
+	err = client.ServerTempPasswordAdd(ctx, ServerTempPasswordOptions{
+		Password:        "pw",
+		Description:     "d",
+		DurationSeconds: 60,
+		TargetChannelID: -1,
+	})
+	if err != nil {
+		t.Fatalf("ServerTempPasswordAdd failed: %v", err)
+	}
+	want = "servertemppasswordadd pw=pw desc=d duration=60"
+	if got := <-cmdCh; got != want {
+		t.Fatalf("unexpected command:\ngot:  %q\nwant: %q", got, want)
+	}
 }
 
 func TestQueryLoginAddDecode(t *testing.T) {
@@ -176,3 +227,35 @@ func TestChannelEditBuildsExpectedCommand(t *testing.T) {
 		t.Fatalf("missing permanent flag: %q", got)
 	}
 }
+
+func TestClientInfoDecodesEmbeddedModelAndExtra(t *testing.T) {
+	conn := newMockServerConn(t, func(cmd string) []string {
+		return []string{
+			"cid=2 client_nickname=Alice client_idle_time=1500 client_created=1700000000 client_description=hi client_future_prop=1",
+			"error id=0 msg=ok",
+		}
+	})
+
+	client, err := NewClientFromConn(conn, Config{})
+	if err != nil {
+		t.Fatalf("NewClientFromConn failed: %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	info, err := client.ClientInfo(ctx, 7)
+	if err != nil {
+		t.Fatalf("ClientInfo failed: %v", err)
+	}
+	if info.ID != 7 || info.ChannelID != 2 || info.Nickname != "Alice" || info.Description != "hi" {
+		t.Fatalf("unexpected client info: %+v", info)
+	}
+	if info.IdleTime != 1500*time.Millisecond || info.Created.Unix() != 1700000000 {
+		t.Fatalf("unexpected times: idle=%v created=%v", info.IdleTime, info.Created)
+	}
+	if len(info.Extra) != 1 || info.Extra["client_future_prop"] != "1" {
+		t.Fatalf("unexpected extra: %v", info.Extra)
+	}
+}
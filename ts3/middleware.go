@@ -0,0 +1,199 @@
+package ts3
+
+import (
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Handler handles one event.
+type Handler func(ev Event)
+
+// Middleware wraps a Handler, e.g. to filter events or to recover panics. A
+// middleware drops an event by not calling next, and may pass on a modified
+// event, e.g. with some rows removed.
+type Middleware func(next Handler) Handler
+
+// Use appends middleware to the chain that runs in front of every Register
+// and On* handler, the first one outermost. Events channels are not
+// affected.
+//
+//	client.Dispatcher().Use(
+//		ts3.Recover(nil),
+//		ts3.ExcludeQueryClients(),
+//	)
+//
+// Each handler builds its own chain, so state a middleware keeps in the
+// closure around its Handler belongs to one handler. The chain runs in the
+// handler goroutine; handlers registered before Use get the new chain with
+// their next event.
+func (d *Dispatcher) Use(middleware ...Middleware) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, mw := range middleware {
+		if mw != nil {
+			d.middlewares = append(d.middlewares, mw)
+		}
+	}
+	d.chainGen.Add(1)
+}
+
+// chain wraps h in the middleware and returns the chain generation it was
+// built from.
+func (d *Dispatcher) chain(h Handler) (Handler, uint64) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	for i := len(d.middlewares) - 1; i >= 0; i-- {
+		h = d.middlewares[i](h)
+	}
+	return h, d.chainGen.Load()
+}
+
+// trackQueryClients remembers which clids belong to query clients. Entries
+// are kept after the client left, because handlers may still have its
+// events queued; a clid reused by a normal client is cleared when it enters.
+func (d *Dispatcher) trackQueryClients(data string) {
+	d.queryMu.Lock()
+	defer d.queryMu.Unlock()
+	for _, row := range decodeNotifyRows(data) {
+		clid := row["clid"]
+		if clid == "" {
+			continue
+		}
+		if row["client_type"] == "1" {
+			if d.queryClients == nil {
+				d.queryClients = make(map[string]bool)
+			}
+			d.queryClients[clid] = true
+		} else {
+			delete(d.queryClients, clid)
+		}
+	}
+}
+
+func (d *Dispatcher) isQueryClient(clid string) bool {
+	if d == nil || clid == "" {
+		return false
+	}
+	d.queryMu.Lock()
+	defer d.queryMu.Unlock()
+	return d.queryClients[clid]
+}
+
+// Recover logs panics of the handler and its inner middleware with their
+// stack instead of crashing the program. A nil l logs through the client's
+// Logger.
+func Recover(l Logger) Middleware {
+	return func(next Handler) Handler {
+		return func(ev Event) {
+			defer func() {
+				if r := recover(); r != nil {
+					switch {
+					case l != nil:
+						l.Printf("ts3: panic in %s handler: %v\n%s", ev.Name, r, debug.Stack())
+					case ev.d != nil && ev.d.logf != nil:
+						ev.d.logf("ts3: panic in %s handler: %v\n%s", ev.Name, r, debug.Stack())
+					}
+				}
+			}()
+			next(ev)
+		}
+	}
+}
+
+// ExcludeQueryClients drops notification rows about ServerQuery clients:
+// rows with client_type=1, and rows whose clid entered as a query client
+// (client moves and leaves carry no client_type). Text messages sent by a
+// query client are dropped as well. Query clients that were online before
+// server events were registered are only known by their client_type.
+func ExcludeQueryClients() Middleware {
+	return filterRows(func(ev Event, row map[string]string) bool {
+		if row["client_type"] == "1" || ev.d.isQueryClient(row["clid"]) {
+			return false
+		}
+		return ev.Name != EventTextMessage || !ev.d.isQueryClient(row["invokerid"])
+	})
+}
+
+// MatchServerGroups keeps the notification rows of clients in at least one
+// of the server groups, e.g. notifycliententerview rows. Rows without
+// client_servergroups are dropped.
+func MatchServerGroups(ids ...int) Middleware {
+	want := idSet(ids)
+	return filterRows(func(_ Event, row map[string]string) bool {
+		for _, id := range strings.Split(row["client_servergroups"], ",") {
+			if want[strings.TrimSpace(id)] {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// MatchChannelGroups keeps the notification rows of clients in one of the
+// channel groups. Rows without client_channel_group_id are dropped.
+func MatchChannelGroups(ids ...int) Middleware {
+	want := idSet(ids)
+	return filterRows(func(_ Event, row map[string]string) bool {
+		return want[row["client_channel_group_id"]]
+	})
+}
+
+// MatchInvoker keeps the notification rows triggered by one of the client
+// unique identifiers. Rows without invokeruid, e.g. those caused by the
+// server, are dropped.
+func MatchInvoker(uids ...string) Middleware {
+	return filterRows(func(_ Event, row map[string]string) bool {
+		uid := row["invokeruid"]
+		return uid != "" && slices.Contains(uids, uid)
+	})
+}
+
+func idSet(ids []int) map[string]bool {
+	set := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		set[strconv.Itoa(id)] = true
+	}
+	return set
+}
+
+// filterRows returns a middleware that passes on the rows keep accepts and
+// drops the event when no row is left. Rows are judged with the keys they
+// inherit from the first row, see notifyRows.
+func filterRows(keep func(ev Event, row map[string]string) bool) Middleware {
+	return func(next Handler) Handler {
+		return func(ev Event) {
+			raw := notifyRows(ev.Data)
+			rows := decodeNotifyRows(ev.Data)
+			if len(rows) != len(raw) {
+				// Undecodable payloads are left to the handler.
+				next(ev)
+				return
+			}
+			kept := raw[:0:0]
+			for i, row := range rows {
+				if keep(ev, row) {
+					kept = append(kept, raw[i])
+				}
+			}
+			switch {
+			case len(kept) == 0:
+				return
+			case len(kept) < len(raw):
+				ev.Data = strings.Join(kept, "|")
+			}
+			next(ev)
+		}
+	}
+}
+
+// decodeNotifyRows decodes the rows of a notification payload into maps. It
+// returns nil when the payload cannot be decoded.
+func decodeNotifyRows(data string) []map[string]string {
+	var rows []map[string]string
+	if err := NewDecoder().Decode(strings.Join(notifyRows(data), "|"), &rows); err != nil {
+		return nil
+	}
+	return rows
+}
@@ -0,0 +1,128 @@
+package ts3
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jkesh/ts3-go/v2/ts3/models"
+)
+
+type captureLogger struct {
+	NopLogger
+	lines chan string
+}
+
+func (l *captureLogger) Printf(format string, v ...interface{}) {
+	l.lines <- fmt.Sprintf(format, v...)
+}
+
+func TestMiddlewareFiltersAndRecovers(t *testing.T) {
+	client := newEventTestClient(t, Config{})
+	logger := &captureLogger{lines: make(chan string, 4)}
+	client.SetLogger(logger)
+
+	var mu sync.Mutex
+	var order []string
+	trace := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ev Event) {
+				mu.Lock()
+				order = append(order, name)
+				mu.Unlock()
+				next(ev)
+			}
+		}
+	}
+	client.Dispatcher().Use(trace("outer"), Recover(nil), ExcludeQueryClients(), MatchServerGroups(6), trace("inner"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	entered := make(chan models.ClientEnterView, 8)
+	if _, err := client.OnClientEnterView(ctx, func(ev models.ClientEnterView) {
+		if ev.Nickname == "Crash" {
+			panic("boom")
+		}
+		entered <- ev
+	}); err != nil {
+		t.Fatalf("OnClientEnterView failed: %v", err)
+	}
+	left := make(chan string, 8)
+	client.Register(EventClientLeftView, func(data string) { left <- data })
+
+	// Query client 9 and the client outside group 6 are dropped from the rows.
+	client.dispatchNotify(`notifycliententerview cfid=0 ctid=1 reasonid=0 clid=5 client_nickname=Alice client_type=0 client_servergroups=6,8` +
+		`|clid=9 client_nickname=Bot client_type=1 client_servergroups=6` +
+		`|clid=7 client_nickname=Bob client_type=0 client_servergroups=8`)
+	select {
+	case ev := <-entered:
+		if ev.ID != 5 || ev.ToChannelID != 1 {
+			t.Fatalf("unexpected enter event: %+v", ev)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("enter event not delivered")
+	}
+
+	client.dispatchNotify(`notifycliententerview ctid=1 clid=11 client_nickname=Crash client_type=0 client_servergroups=6`)
+	select {
+	case line := <-logger.lines:
+		if !strings.Contains(line, "panic in notifycliententerview handler: boom") {
+			t.Fatalf("unexpected log line: %q", line)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("panic not logged")
+	}
+
+	// The handler survived the panic; the left event of the query client is
+	// dropped, as it carries no client_type and no groups.
+	client.dispatchNotify(`notifyclientleftview cfid=1 ctid=0 reasonid=8 clid=9`)
+	client.dispatchNotify(`notifycliententerview ctid=1 clid=12 client_nickname=Carol client_type=0 client_servergroups=6`)
+	select {
+	case ev := <-entered:
+		if ev.ID != 12 {
+			t.Fatalf("unexpected enter event: %+v", ev)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("enter event after panic not delivered")
+	}
+	select {
+	case data := <-left:
+		t.Fatalf("unexpected left event: %q", data)
+	case <-time.After(50 * time.Millisecond):
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(order) < 2 || order[0] != "outer" {
+		t.Fatalf("unexpected middleware order: %v", order)
+	}
+}
+
+func TestMatchInvokerAndChannelGroups(t *testing.T) {
+	pass := func(mw Middleware, ev Event) (Event, bool) {
+		var got Event
+		called := false
+		mw(func(ev Event) { got, called = ev, true })(ev)
+		return got, called
+	}
+
+	text := Event{Name: EventTextMessage, Data: `targetmode=3 msg=hi invokerid=4 invokeruid=abc=`}
+	if _, ok := pass(MatchInvoker("abc="), text); !ok {
+		t.Fatalf("expected invoker abc= to match")
+	}
+	if _, ok := pass(MatchInvoker("other="), text); ok {
+		t.Fatalf("expected invoker other= not to match")
+	}
+	if _, ok := pass(MatchInvoker("abc="), Event{Name: EventChannelDeleted, Data: "cid=3"}); ok {
+		t.Fatalf("expected event without invoker to be dropped")
+	}
+
+	enter := Event{Name: EventClientEnterView, Data: `clid=1 client_channel_group_id=5|clid=2 client_channel_group_id=8`}
+	ev, ok := pass(MatchChannelGroups(8), enter)
+	if !ok || ev.Data != "clid=2 client_channel_group_id=8" {
+		t.Fatalf("unexpected filtered event: %+v, %v", ev, ok)
+	}
+}
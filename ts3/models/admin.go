@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 // ServerGroupClient is one row from "servergroupclientlist".
 type ServerGroupClient struct {
 	ServerGroupID    int    `ts3:"sgid"`
@@ -24,55 +26,55 @@ type ChannelGroup struct {
 
 // ChannelGroupClient is one row from "channelgroupclientlist".
 type ChannelGroupClient struct {
-	ChannelGroupID    int    `ts3:"cgid"`
-	ChannelID         int    `ts3:"cid"`
-	ClientDBID        int    `ts3:"cldbid"`
-	Nickname          string `ts3:"name"`
-	UniqueIdentifier  string `ts3:"cluid"`
-	LastNickname      string `ts3:"client_nickname"`
-	LastConnectedTime int64  `ts3:"client_lastconnected"`
+	ChannelGroupID    int       `ts3:"cgid"`
+	ChannelID         int       `ts3:"cid"`
+	ClientDBID        int       `ts3:"cldbid"`
+	Nickname          string    `ts3:"name"`
+	UniqueIdentifier  string    `ts3:"cluid"`
+	LastNickname      string    `ts3:"client_nickname"`
+	LastConnectedTime time.Time `ts3:"client_lastconnected,unix"`
 }
 
 // BanEntry is one row from "banlist".
 type BanEntry struct {
-	BanID         int    `ts3:"banid"`
-	IP            string `ts3:"ip"`
-	Name          string `ts3:"name"`
-	UID           string `ts3:"uid"`
-	Created       int64  `ts3:"created"`
-	Duration      int64  `ts3:"duration"`
-	InvokerName   string `ts3:"invokername"`
-	InvokerUID    string `ts3:"invokeruid"`
-	LastNickname  string `ts3:"lastnickname"`
-	Reason        string `ts3:"reason"`
-	Enforcements  int    `ts3:"enforcements"`
-	TargetMode    int    `ts3:"targetmode"`
-	Target        string `ts3:"target"`
-	TargetNick    string `ts3:"targetnick"`
-	TargetUID     string `ts3:"targetuid"`
-	TargetIP      string `ts3:"targetip"`
-	TargetName    string `ts3:"targetname"`
-	Expires       int64  `ts3:"expires"`
-	EnforcedTimes int    `ts3:"count"`
+	BanID         int           `ts3:"banid"`
+	IP            string        `ts3:"ip"`
+	Name          string        `ts3:"name"`
+	UID           string        `ts3:"uid"`
+	Created       time.Time     `ts3:"created,unix"`
+	Duration      time.Duration `ts3:"duration,s"` // 0 means permanent
+	InvokerName   string        `ts3:"invokername"`
+	InvokerUID    string        `ts3:"invokeruid"`
+	LastNickname  string        `ts3:"lastnickname"`
+	Reason        string        `ts3:"reason"`
+	Enforcements  int           `ts3:"enforcements"`
+	TargetMode    int           `ts3:"targetmode"`
+	Target        string        `ts3:"target"`
+	TargetNick    string        `ts3:"targetnick"`
+	TargetUID     string        `ts3:"targetuid"`
+	TargetIP      string        `ts3:"targetip"`
+	TargetName    string        `ts3:"targetname"`
+	Expires       time.Duration `ts3:"expires,s"`
+	EnforcedTimes int           `ts3:"count"`
 }
 
 // ComplainEntry is one row from "complainlist".
 type ComplainEntry struct {
-	TargetClientDBID int    `ts3:"tcldbid"`
-	FromClientDBID   int    `ts3:"fcldbid"`
-	TargetName       string `ts3:"tname"`
-	FromName         string `ts3:"fname"`
-	Message          string `ts3:"message"`
-	Timestamp        int64  `ts3:"timestamp"`
+	TargetClientDBID int       `ts3:"tcldbid"`
+	FromClientDBID   int       `ts3:"fcldbid"`
+	TargetName       string    `ts3:"tname"`
+	FromName         string    `ts3:"fname"`
+	Message          string    `ts3:"message"`
+	Timestamp        time.Time `ts3:"timestamp,unix"`
 }
 
 // ServerTempPassword is one row from "servertemppasswordlist".
 type ServerTempPassword struct {
-	Password      string `ts3:"pw"`
-	Description   string `ts3:"desc"`
-	StartTime     int64  `ts3:"start"`
-	EndTime       int64  `ts3:"end"`
-	TargetChannel int    `ts3:"tcid"`
+	Password      string    `ts3:"pw"`
+	Description   string    `ts3:"desc"`
+	StartTime     time.Time `ts3:"start,unix"`
+	EndTime       time.Time `ts3:"end,unix"`
+	TargetChannel int       `ts3:"tcid"`
 }
 
 // QueryLoginCredentials is returned by "queryloginadd".
@@ -85,10 +87,10 @@ type QueryLoginCredentials struct {
 
 // QueryLogin is one row from "queryloginlist".
 type QueryLogin struct {
-	ClientDBID int    `ts3:"cldbid"`
-	ServerID   int    `ts3:"sid"`
-	LoginName  string `ts3:"client_login_name"`
-	CreatedAt  int64  `ts3:"created_at"`
+	ClientDBID int       `ts3:"cldbid"`
+	ServerID   int       `ts3:"sid"`
+	LoginName  string    `ts3:"client_login_name"`
+	CreatedAt  time.Time `ts3:"created_at,unix"`
 }
 
 // PermissionEntry is one row from permlist or *permlist commands.
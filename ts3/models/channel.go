@@ -1,20 +1,32 @@
 package models
 
+import "time"
+
 // Channel channel info
 type Channel struct {
-	ID                   int    `ts3:"cid"`
-	ParentID             int    `ts3:"pid"`
-	Order                int    `ts3:"channel_order"`
-	Name                 string `ts3:"channel_name"`
-	Topic                string `ts3:"channel_topic"`
-	IsDefault            int    `ts3:"channel_flag_default"`
-	Password             int    `ts3:"channel_flag_password"`
-	Permanent            int    `ts3:"channel_flag_permanent"`
-	SemiPermanent        int    `ts3:"channel_flag_semi_permanent"`
-	Codec                int    `ts3:"channel_codec"`
-	CodecQuality         int    `ts3:"channel_codec_quality"`
-	NeededSubscribePower int    `ts3:"channel_needed_subscribe_power"`
-	TotalClients         int    `ts3:"total_clients"`
-	MaxClients           int    `ts3:"channel_maxclients"`
-	FamilyMaxClients     int    `ts3:"channel_maxfamilyclients"`
+	ID                   int           `ts3:"cid"`
+	ParentID             int           `ts3:"pid"`
+	Order                int           `ts3:"channel_order"`
+	Name                 string        `ts3:"channel_name"`
+	Topic                string        `ts3:"channel_topic"`
+	IsDefault            int           `ts3:"channel_flag_default"`
+	Password             int           `ts3:"channel_flag_password"`
+	Permanent            int           `ts3:"channel_flag_permanent"`
+	SemiPermanent        int           `ts3:"channel_flag_semi_permanent"`
+	Codec                int           `ts3:"channel_codec"`
+	CodecQuality         int           `ts3:"channel_codec_quality"`
+	NeededSubscribePower int           `ts3:"channel_needed_subscribe_power"`
+	TotalClients         int           `ts3:"total_clients"`
+	MaxClients           int           `ts3:"channel_maxclients"`
+	FamilyMaxClients     int           `ts3:"channel_maxfamilyclients"`
+	NeededTalkPower      int           `ts3:"channel_needed_talk_power"`
+	IconID               int64         `ts3:"channel_icon_id"`
+	SecondsEmpty         time.Duration `ts3:"seconds_empty,s"` // requires -secondsempty; -1 s while occupied
+
+	// Only returned by "channelinfo".
+	Description string            `ts3:"channel_description"`
+	FilePath    string            `ts3:"channel_filepath"`
+	DeleteDelay time.Duration     `ts3:"channel_delete_delay,s"`
+	Unencrypted int               `ts3:"channel_codec_is_unencrypted"`
+	Extra       map[string]string `ts3:",extra"`
 }
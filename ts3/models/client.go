@@ -1,56 +1,70 @@
 package models
 
+import "time"
+
 // OnlineClient is one row from "clientlist".
 type OnlineClient struct {
-	ID                int    `ts3:"clid"`
-	ChannelID         int    `ts3:"cid"`
-	DatabaseID        int    `ts3:"client_database_id"`
-	Nickname          string `ts3:"client_nickname"`
-	Type              int    `ts3:"client_type"` // 0=voice client, 1=server query client
-	Away              int    `ts3:"client_away"`
-	AwayMessage       string `ts3:"client_away_message"`
-	InputMuted        int    `ts3:"client_input_muted"`
-	OutputMuted       int    `ts3:"client_output_muted"`
-	OutputOnlyMuted   int    `ts3:"client_outputonly_muted"`
-	InputHardware     int    `ts3:"client_input_hardware"`
-	OutputHardware    int    `ts3:"client_output_hardware"`
-	TalkPower         int    `ts3:"client_talk_power"`
-	IsTalker          int    `ts3:"client_is_talker"`
-	IsPrioritySpeaker int    `ts3:"client_is_priority_speaker"`
-	IsRecording       int    `ts3:"client_is_recording"`
-	UniqueIdentifier  string `ts3:"client_unique_identifier"` // requires -uid in command options
-	ServerGroups      []int  `ts3:"client_servergroups"`      // requires -groups in command options
-	ChannelGroupID    int    `ts3:"client_channel_group_id"`
+	ID                             int    `ts3:"clid"`
+	ChannelID                      int    `ts3:"cid"`
+	DatabaseID                     int    `ts3:"client_database_id"`
+	Nickname                       string `ts3:"client_nickname"`
+	Type                           int    `ts3:"client_type"` // 0=voice client, 1=server query client
+	Away                           int    `ts3:"client_away"`
+	AwayMessage                    string `ts3:"client_away_message"`
+	InputMuted                     int    `ts3:"client_input_muted"`
+	OutputMuted                    int    `ts3:"client_output_muted"`
+	OutputOnlyMuted                int    `ts3:"client_outputonly_muted"`
+	InputHardware                  int    `ts3:"client_input_hardware"`
+	OutputHardware                 int    `ts3:"client_output_hardware"`
+	TalkPower                      int    `ts3:"client_talk_power"`
+	IsTalker                       int    `ts3:"client_is_talker"`
+	IsPrioritySpeaker              int    `ts3:"client_is_priority_speaker"`
+	IsRecording                    int    `ts3:"client_is_recording"`
+	IsChannelCommander             int    `ts3:"client_is_channel_commander"`
+	UniqueIdentifier               string `ts3:"client_unique_identifier"` // requires -uid in command options
+	ServerGroups                   []int  `ts3:"client_servergroups"`      // requires -groups in command options
+	ChannelGroupID                 int    `ts3:"client_channel_group_id"`
+	ChannelGroupInheritedChannelID int    `ts3:"client_channel_group_inherited_channel_id"`
+
+	IdleTime      time.Duration `ts3:"client_idle_time,ms"`       // requires -times
+	Created       time.Time     `ts3:"client_created,unix"`       // requires -times
+	LastConnected time.Time     `ts3:"client_lastconnected,unix"` // requires -times
+	Version       string        `ts3:"client_version"`            // requires -info
+	Platform      string        `ts3:"client_platform"`           // requires -info
+	Country       string        `ts3:"client_country"`            // requires -country
+	IP            string        `ts3:"connection_client_ip"`      // requires -ip
+	IconID        int64         `ts3:"client_icon_id"`            // requires -icon
+	Badges        string        `ts3:"client_badges"`             // requires -badges
 }
 
-// ClientInfo is returned by "clientinfo".
+// ClientInfo is returned by "clientinfo". Properties that have no field are
+// kept in Extra.
 type ClientInfo struct {
-	ID               int    `ts3:"clid"`
-	ChannelID        int    `ts3:"cid"`
-	DatabaseID       int    `ts3:"client_database_id"`
-	Nickname         string `ts3:"client_nickname"`
-	Type             int    `ts3:"client_type"`
-	UniqueIdentifier string `ts3:"client_unique_identifier"`
-	Created          int64  `ts3:"client_created"`
-	LastConnected    int64  `ts3:"client_lastconnected"`
-	Connections      int    `ts3:"client_totalconnections"`
-	Country          string `ts3:"client_country"`
-	IdleTimeMS       int64  `ts3:"client_idle_time"`
-	Platform         string `ts3:"client_platform"`
-	Version          string `ts3:"client_version"`
-	InputMuted       int    `ts3:"client_input_muted"`
-	OutputMuted      int    `ts3:"client_output_muted"`
-	TalkPower        int    `ts3:"client_talk_power"`
-	ServerGroups     []int  `ts3:"client_servergroups"`
-	ChannelGroupID   int    `ts3:"client_channel_group_id"`
+	OnlineClient
+
+	Description                string        `ts3:"client_description"`
+	LoginName                  string        `ts3:"client_login_name"`
+	Connections                int           `ts3:"client_totalconnections"`
+	ConnectedTime              time.Duration `ts3:"connection_connected_time,ms"`
+	BytesSentTotal             int64         `ts3:"connection_bytes_sent_total"`
+	BytesReceivedTotal         int64         `ts3:"connection_bytes_received_total"`
+	MonthBytesUploaded         int64         `ts3:"client_month_bytes_uploaded"`
+	MonthBytesDownloaded       int64         `ts3:"client_month_bytes_downloaded"`
+	TotalBytesUploaded         int64         `ts3:"client_total_bytes_uploaded"`
+	TotalBytesDownloaded       int64         `ts3:"client_total_bytes_downloaded"`
+	NeededServerQueryViewPower int           `ts3:"client_needed_serverquery_view_power"`
+	MyTeamSpeakID              string        `ts3:"client_myteamspeak_id"`
+	Base64HashClientUID        string        `ts3:"client_base64HashClientUID"`
+
+	Extra map[string]string `ts3:",extra"`
 }
 
 // DBClient is one row from "clientdblist".
 type DBClient struct {
-	DatabaseID       int    `ts3:"cldbid"`
-	UniqueIdentifier string `ts3:"client_unique_identifier"`
-	Nickname         string `ts3:"client_nickname"`
-	Created          int64  `ts3:"client_created"`       // unix timestamp
-	LastConnected    int64  `ts3:"client_lastconnected"` // unix timestamp
-	TotalConnections int    `ts3:"client_totalconnections"`
+	DatabaseID       int       `ts3:"cldbid"`
+	UniqueIdentifier string    `ts3:"client_unique_identifier"`
+	Nickname         string    `ts3:"client_nickname"`
+	Created          time.Time `ts3:"client_created,unix"`
+	LastConnected    time.Time `ts3:"client_lastconnected,unix"`
+	TotalConnections int       `ts3:"client_totalconnections"`
 }
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 // Response represents the trailing "error id=... msg=..." line fields.
 type Response struct {
 	ID      int    `ts3:"id"`
@@ -20,12 +22,12 @@ type VersionInfo struct {
 
 // HostInfo is returned by the "hostinfo" command.
 type HostInfo struct {
-	InstanceUptime int64 `ts3:"instance_uptime"`
-	HostTimestamp  int64 `ts3:"host_timestamp_utc"`
-	VirtualServers int   `ts3:"virtualservers_running_total"`
-	ChannelsOnline int   `ts3:"virtualservers_total_channels_online"`
-	ClientsOnline  int   `ts3:"virtualservers_total_clients_online"`
-	QueriesOnline  int   `ts3:"virtualservers_total_query_clients_online"`
+	InstanceUptime time.Duration `ts3:"instance_uptime,s"`
+	HostTimestamp  time.Time     `ts3:"host_timestamp_utc,unix"`
+	VirtualServers int           `ts3:"virtualservers_running_total"`
+	ChannelsOnline int           `ts3:"virtualservers_total_channels_online"`
+	ClientsOnline  int           `ts3:"virtualservers_total_clients_online"`
+	QueriesOnline  int           `ts3:"virtualservers_total_query_clients_online"`
 }
 
 // WhoAmI is returned by the "whoami" command.
@@ -0,0 +1,101 @@
+package models
+
+// Invoker identifies the client that triggered an event. All fields are zero
+// when the server caused it.
+type Invoker struct {
+	InvokerID   int    `ts3:"invokerid"`
+	InvokerName string `ts3:"invokername"`
+	InvokerUID  string `ts3:"invokeruid"`
+}
+
+// ClientEnterView is sent as "notifycliententerview" when a client connects
+// or becomes visible.
+//
+// OnlineClient.ChannelID is not sent; use ToChannelID.
+type ClientEnterView struct {
+	OnlineClient
+	Invoker
+	FromChannelID int    `ts3:"cfid"` // 0 when the client connected
+	ToChannelID   int    `ts3:"ctid"`
+	ReasonID      int    `ts3:"reasonid"`
+	Description   string `ts3:"client_description"`
+}
+
+// ClientLeftView is sent as "notifyclientleftview" when a client disconnects,
+// is kicked or banned, or leaves the view.
+type ClientLeftView struct {
+	Invoker
+	ClientID      int    `ts3:"clid"`
+	FromChannelID int    `ts3:"cfid"`
+	ToChannelID   int    `ts3:"ctid"` // 0 when the client left the server
+	ReasonID      int    `ts3:"reasonid"`
+	ReasonMessage string `ts3:"reasonmsg"`
+	BanTime       int    `ts3:"bantime"` // seconds, only for bans
+}
+
+// ClientMoved is sent as "notifyclientmoved".
+type ClientMoved struct {
+	Invoker
+	ClientID    int `ts3:"clid"`
+	ToChannelID int `ts3:"ctid"`
+	ReasonID    int `ts3:"reasonid"`
+}
+
+// TextMessage is sent as "notifytextmessage".
+type TextMessage struct {
+	Invoker
+	TargetMode int    `ts3:"targetmode"` // 1=client, 2=channel, 3=server
+	Message    string `ts3:"msg"`
+	Target     int    `ts3:"target"` // only for private messages
+}
+
+// ChannelCreated is sent as "notifychannelcreated". Properties left at their
+// defaults may be omitted by the server.
+type ChannelCreated struct {
+	Channel
+	Invoker
+	ParentID int `ts3:"cpid"` // the notification uses cpid instead of pid
+}
+
+// ChannelEdited is sent as "notifychanneledited". Changes holds the modified
+// channel properties, e.g. "channel_name".
+type ChannelEdited struct {
+	Invoker
+	ChannelID int               `ts3:"cid"`
+	ReasonID  int               `ts3:"reasonid"`
+	Changes   map[string]string `ts3:",extra"`
+}
+
+// ChannelDeleted is sent as "notifychanneldeleted".
+type ChannelDeleted struct {
+	Invoker
+	ChannelID int `ts3:"cid"`
+}
+
+// ChannelMoved is sent as "notifychannelmoved".
+type ChannelMoved struct {
+	Invoker
+	ChannelID int `ts3:"cid"`
+	ParentID  int `ts3:"cpid"`
+	Order     int `ts3:"order"`
+	ReasonID  int `ts3:"reasonid"`
+}
+
+// ServerEdited is sent as "notifyserveredited". Changes holds the modified
+// server properties, e.g. "virtualserver_name".
+type ServerEdited struct {
+	Invoker
+	ReasonID int               `ts3:"reasonid"`
+	Changes  map[string]string `ts3:",extra"`
+}
+
+// TokenUsed is sent as "notifytokenused".
+type TokenUsed struct {
+	ClientID       int    `ts3:"clid"`
+	ClientDBID     int    `ts3:"cldbid"`
+	ClientUID      string `ts3:"cluid"`
+	Token          string `ts3:"token"`
+	TokenCustomSet string `ts3:"tokencustomset"`
+	TokenID1       int    `ts3:"token1"` // group id
+	TokenID2       int    `ts3:"token2"` // channel id
+}
@@ -1,35 +1,49 @@
 package models
 
+import "time"
+
 // VirtualServer resp for virtual server
 type VirtualServer struct {
-	ID            int    `ts3:"virtualserver_id"`
-	Port          int    `ts3:"virtualserver_port"`
-	Status        string `ts3:"virtualserver_status"` // online, offline ...
-	ClientsOnline int    `ts3:"virtualserver_clientsonline"`
-	QueryClients  int    `ts3:"virtualserver_queryclientsonline"`
-	MaxClients    int    `ts3:"virtualserver_maxclients"`
-	Uptime        int64  `ts3:"virtualserver_uptime"`
-	Name          string `ts3:"virtualserver_name"`
-	AutoStart     int    `ts3:"virtualserver_autostart"`
-	MachineID     string `ts3:"virtualserver_machine_id"`
+	ID            int           `ts3:"virtualserver_id"`
+	Port          int           `ts3:"virtualserver_port"`
+	Status        string        `ts3:"virtualserver_status"` // online, offline ...
+	ClientsOnline int           `ts3:"virtualserver_clientsonline"`
+	QueryClients  int           `ts3:"virtualserver_queryclientsonline"`
+	MaxClients    int           `ts3:"virtualserver_maxclients"`
+	Uptime        time.Duration `ts3:"virtualserver_uptime,s"`
+	Name          string        `ts3:"virtualserver_name"`
+	AutoStart     int           `ts3:"virtualserver_autostart"`
+	MachineID     string        `ts3:"virtualserver_machine_id"`
 }
 
-// ServerInfo command serverInfo
+// ServerInfo command serverInfo. Properties that have no field are kept in
+// Extra.
 type ServerInfo struct {
-	ID                  int    `ts3:"virtualserver_id"`
-	Name                string `ts3:"virtualserver_name"`
-	WelcomeMessage      string `ts3:"virtualserver_welcomemessage"`
-	MaxClients          int    `ts3:"virtualserver_maxclients"`
-	Platform            string `ts3:"virtualserver_platform"`
-	Version             string `ts3:"virtualserver_version"`
-	Password            string `ts3:"virtualserver_password"`
-	Created             int64  `ts3:"virtualserver_created"`
-	Uptime              int64  `ts3:"virtualserver_uptime"`
-	Hostmessage         string `ts3:"virtualserver_hostmessage"`
-	HostmessageMode     int    `ts3:"virtualserver_hostmessage_mode"`
-	FileBase            string `ts3:"virtualserver_filebase"`
-	DefaultServerGroup  int    `ts3:"virtualserver_default_server_group"`
-	DefaultChannelGroup int    `ts3:"virtualserver_default_channel_group"`
-	DownloadQuota       int64  `ts3:"virtualserver_download_quota"`
-	UploadQuota         int64  `ts3:"virtualserver_upload_quota"`
+	VirtualServer
+
+	UniqueIdentifier            string        `ts3:"virtualserver_unique_identifier"`
+	WelcomeMessage              string        `ts3:"virtualserver_welcomemessage"`
+	Platform                    string        `ts3:"virtualserver_platform"`
+	Version                     string        `ts3:"virtualserver_version"`
+	Password                    string        `ts3:"virtualserver_password"`
+	Created                     time.Time     `ts3:"virtualserver_created,unix"`
+	Hostmessage                 string        `ts3:"virtualserver_hostmessage"`
+	HostmessageMode             int           `ts3:"virtualserver_hostmessage_mode"`
+	HostbannerURL               string        `ts3:"virtualserver_hostbanner_url"`
+	HostbannerGFXURL            string        `ts3:"virtualserver_hostbanner_gfx_url"`
+	HostbuttonURL               string        `ts3:"virtualserver_hostbutton_url"`
+	IconID                      int64         `ts3:"virtualserver_icon_id"`
+	ChannelsOnline              int           `ts3:"virtualserver_channelsonline"`
+	ReservedSlots               int           `ts3:"virtualserver_reserved_slots"`
+	FileBase                    string        `ts3:"virtualserver_filebase"`
+	DefaultServerGroup          int           `ts3:"virtualserver_default_server_group"`
+	DefaultChannelGroup         int           `ts3:"virtualserver_default_channel_group"`
+	DownloadQuota               int64         `ts3:"virtualserver_download_quota"`
+	UploadQuota                 int64         `ts3:"virtualserver_upload_quota"`
+	MinClientVersion            int64         `ts3:"virtualserver_min_client_version"`
+	NeededIdentitySecurityLevel int           `ts3:"virtualserver_needed_identity_security_level"`
+	TotalPing                   float64       `ts3:"virtualserver_total_ping"`
+	ComplainAutobanTime         time.Duration `ts3:"virtualserver_complain_autoban_time,s"`
+
+	Extra map[string]string `ts3:",extra"`
 }
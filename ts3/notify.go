@@ -0,0 +1,404 @@
+package ts3
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// Notification scope names of servernotifyregister.
+const (
+	NotifyServer      = "server"
+	NotifyChannel     = "channel"
+	NotifyTextServer  = "textserver"
+	NotifyTextChannel = "textchannel"
+	NotifyTextPrivate = "textprivate"
+	NotifyTokenUsed   = "tokenused"
+)
+
+// NotifyScope is one servernotifyregister target.
+type NotifyScope struct {
+	// Event is one of the Notify* scope names.
+	Event string
+	// ChannelID selects the channel of NotifyChannel. 0 sends no id and
+	// leaves the choice to the server.
+	ChannelID int
+}
+
+// String returns the scope as command parameters, e.g. "event=channel id=5".
+func (s NotifyScope) String() string {
+	if s.Event == NotifyChannel && s.ChannelID > 0 {
+		return "event=channel id=" + strconv.Itoa(s.ChannelID)
+	}
+	return "event=" + s.Event
+}
+
+func (s NotifyScope) command() string {
+	return "servernotifyregister " + s.String()
+}
+
+// Scopes used by the typed handlers.
+var (
+	scopeServer      = NotifyScope{Event: NotifyServer}
+	scopeChannel     = NotifyScope{Event: NotifyChannel}
+	scopeTextServer  = NotifyScope{Event: NotifyTextServer}
+	scopeTextChannel = NotifyScope{Event: NotifyTextChannel}
+	scopeTextPrivate = NotifyScope{Event: NotifyTextPrivate}
+	scopeTokenUsed   = NotifyScope{Event: NotifyTokenUsed}
+)
+
+// eventScopes lists the registrations each event needs.
+var eventScopes = map[string][]NotifyScope{
+	EventClientEnterView: {scopeServer},
+	EventClientLeftView:  {scopeServer},
+	EventServerEdited:    {scopeServer},
+	EventClientMoved:     {scopeChannel},
+	EventChannelCreated:  {scopeChannel},
+	EventChannelEdited:   {scopeChannel},
+	EventChannelDeleted:  {scopeChannel},
+	EventChannelMoved:    {scopeChannel},
+	EventTextMessage:     {scopeTextPrivate, scopeTextServer, scopeTextChannel},
+	EventTokenUsed:       {scopeTokenUsed},
+}
+
+// NotifyRegistration is one active entry of the notification registry.
+type NotifyRegistration struct {
+	Scope NotifyScope
+	// Handlers is the number of typed handlers that need the scope.
+	Handlers int
+	// Pinned is true when the scope was registered with a Register*Events
+	// call; it then stays until UnregisterNotify.
+	Pinned bool
+	// Applied is false when registering the scope on the server failed. The
+	// next handler or Register*Events call that needs the scope retries it.
+	Applied bool
+}
+
+func (r *NotifyRegistration) active() bool {
+	return r.Handlers > 0 || r.Pinned
+}
+
+// NotifyRegistrations returns the active notification scopes in registration
+// order. They are registered again after Use, UseByPort and reconnects.
+func (c *Client) NotifyRegistrations() []NotifyRegistration {
+	c.notifyMu.Lock()
+	defer c.notifyMu.Unlock()
+	out := make([]NotifyRegistration, 0, len(c.notifyRegs))
+	for _, reg := range c.notifyRegs {
+		out = append(out, *reg)
+	}
+	return out
+}
+
+// notifyUnsupported returns an error when the transport cannot receive
+// notifications.
+func (c *Client) notifyUnsupported() error {
+	switch {
+	case c.isWebQuery() && c.web.poller == nil:
+		return errWebQueryNotifyUnsupported
+	case c.pool != nil:
+		return errPoolNotifyUnsupported
+	}
+	return nil
+}
+
+// RegisterServerEvents subscribes to server-level client enter/leave and server
+// edit events.
+func (c *Client) RegisterServerEvents(ctx context.Context) error {
+	return c.pinNotify(ctx, scopeServer)
+}
+
+// RegisterChannelEvents subscribes to channel-level events.
+//
+// channelID is optional:
+//   - 0: current/default behavior
+//   - >0: explicit channel id
+func (c *Client) RegisterChannelEvents(ctx context.Context, channelID int) error {
+	return c.pinNotify(ctx, NotifyScope{Event: NotifyChannel, ChannelID: channelID})
+}
+
+// RegisterTextEvents subscribes to private, channel and server text message events.
+func (c *Client) RegisterTextEvents(ctx context.Context) error {
+	return c.pinNotify(ctx, scopeTextPrivate, scopeTextServer, scopeTextChannel)
+}
+
+// RegisterTokenEvents subscribes to privilege key usage events.
+func (c *Client) RegisterTokenEvents(ctx context.Context) error {
+	return c.pinNotify(ctx, scopeTokenUsed)
+}
+
+// pinNotify registers scopes until UnregisterNotify. Scopes that are active
+// already are not sent again.
+func (c *Client) pinNotify(ctx context.Context, scopes ...NotifyScope) error {
+	if err := c.notifyUnsupported(); err != nil {
+		return err
+	}
+
+	c.notifyMu.Lock()
+	defer c.notifyMu.Unlock()
+	for _, scope := range scopes {
+		if err := c.scopeUnsupported(scope); err != nil {
+			return err
+		}
+		reg := c.notifyReg(scope)
+		if !reg.active() || !reg.Applied {
+			if err := c.sendNotify(ctx, scope); err != nil {
+				c.pruneNotify()
+				return err
+			}
+			reg.Applied = true
+		}
+		reg.Pinned = true
+	}
+	c.syncNotifySession()
+	return nil
+}
+
+// acquireNotify adds a handler reference to scopes and registers the ones
+// that were not active yet.
+func (c *Client) acquireNotify(ctx context.Context, scopes []NotifyScope) error {
+	for _, scope := range scopes {
+		if err := c.scopeUnsupported(scope); err != nil {
+			return err
+		}
+	}
+
+	c.notifyMu.Lock()
+	defer c.notifyMu.Unlock()
+
+	for i, scope := range scopes {
+		reg := c.notifyReg(scope)
+		if !reg.active() || !reg.Applied {
+			if err := c.sendNotify(ctx, scope); err != nil {
+				c.pruneNotify()
+				_ = c.releaseNotifyLocked(ctx, scopes[:i])
+				return err
+			}
+			reg.Applied = true
+		}
+		reg.Handlers++
+	}
+	c.syncNotifySession()
+	return nil
+}
+
+// releaseNotify drops a handler reference from scopes. Scopes nobody needs
+// any more are removed on the server; as servernotifyunregister clears every
+// registration, the remaining ones are registered again right after it,
+// without other commands in between.
+func (c *Client) releaseNotify(scopes []NotifyScope) error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	c.notifyMu.Lock()
+	defer c.notifyMu.Unlock()
+	return c.releaseNotifyLocked(ctx, scopes)
+}
+
+func (c *Client) releaseNotifyLocked(ctx context.Context, scopes []NotifyScope) error {
+	for _, scope := range scopes {
+		if reg := c.findNotifyReg(scope); reg != nil && reg.Handlers > 0 {
+			reg.Handlers--
+		}
+	}
+	if !c.pruneNotify() {
+		return nil
+	}
+	c.syncNotifySession()
+
+	select {
+	case <-c.quit:
+		return nil
+	default:
+	}
+	return c.applyNotifyLocked(ctx, true)
+}
+
+// reapplyNotify registers every active scope again. The server forgets
+// registrations when another virtual server is selected.
+func (c *Client) reapplyNotify(ctx context.Context) error {
+	c.notifyMu.Lock()
+	defer c.notifyMu.Unlock()
+	return c.reapplyNotifyLocked(ctx)
+}
+
+func (c *Client) reapplyNotifyLocked(ctx context.Context) error {
+	return c.applyNotifyLocked(ctx, false)
+}
+
+// applyNotifyLocked registers every scope of the registry in one batch,
+// after servernotifyunregister when unregister is set. Entries that could
+// not be registered are logged and marked as not applied.
+func (c *Client) applyNotifyLocked(ctx context.Context, unregister bool) error {
+	cmds := make([]string, 0, len(c.notifyRegs)+1)
+	if unregister {
+		cmds = append(cmds, "servernotifyunregister")
+	}
+	for _, reg := range c.notifyRegs {
+		cmds = append(cmds, reg.Scope.command())
+	}
+
+	n, err := c.execNotifyBatch(ctx, cmds)
+	if err == nil {
+		for _, reg := range c.notifyRegs {
+			reg.Applied = true
+		}
+		return nil
+	}
+	if unregister {
+		if n == 0 {
+			// The server still has every registration.
+			return err
+		}
+		n--
+	}
+	failed := c.notifyRegs[n]
+	for _, reg := range c.notifyRegs[n:] {
+		reg.Applied = false
+	}
+	c.logf("ts3: restore notifications (%s): %v", failed.Scope, err)
+	return fmt.Errorf("ts3: restore notifications (%s): %w", failed.Scope, err)
+}
+
+// UnregisterNotify unsubscribes current query client from notifications and
+// clears the registry. Handlers stay registered but receive nothing until
+// their scopes are registered again.
+func (c *Client) UnregisterNotify(ctx context.Context) error {
+	if err := c.notifyUnsupported(); err != nil {
+		return err
+	}
+
+	c.notifyMu.Lock()
+	defer c.notifyMu.Unlock()
+	if err := c.execNotify(ctx, "servernotifyunregister"); err != nil {
+		return err
+	}
+	c.resetNotifyLocked()
+	return nil
+}
+
+// resetNotify forgets all registrations, e.g. after logout.
+func (c *Client) resetNotify() {
+	c.notifyMu.Lock()
+	defer c.notifyMu.Unlock()
+	c.resetNotifyLocked()
+}
+
+func (c *Client) resetNotifyLocked() {
+	c.notifyRegs = nil
+	c.syncNotifySession()
+}
+
+// eventUnsupported returns an error when the WebQuery poller does not
+// synthesize eventName, e.g. client events with only PollServer.
+func (c *Client) eventUnsupported(eventName string) error {
+	if c.isWebQuery() && !c.web.poller.watchesEvent(eventName) {
+		return errWebQueryNotifyUnsupported
+	}
+	return nil
+}
+
+// scopeUnsupported returns an error when the WebQuery poller cannot deliver
+// any event of scope.
+func (c *Client) scopeUnsupported(scope NotifyScope) error {
+	if c.isWebQuery() && !c.web.poller.watches(scope) {
+		return errWebQueryNotifyUnsupported
+	}
+	return nil
+}
+
+func (c *Client) sendNotify(ctx context.Context, scope NotifyScope) error {
+	return c.execNotify(ctx, scope.command())
+}
+
+// execNotify sends a notification command. WebQuery clients only keep the
+// registry, as their events come from the poller.
+func (c *Client) execNotify(ctx context.Context, cmd string) error {
+	if c.isWebQuery() {
+		return nil
+	}
+	_, err := c.Exec(ctx, cmd)
+	return err
+}
+
+// execNotifyBatch sends cmds back to back while holding the command lock, so
+// no other command runs between them. It returns the index of the command
+// that failed.
+func (c *Client) execNotifyBatch(ctx context.Context, cmds []string) (int, error) {
+	if c.isWebQuery() || len(cmds) == 0 {
+		return len(cmds), nil
+	}
+	if !c.beginExec() {
+		return 0, errClientClosed
+	}
+	defer c.execWG.Done()
+
+	if c.cmdTimeout > 0 {
+		if _, ok := ctx.Deadline(); !ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, c.cmdTimeout)
+			defer cancel()
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, cmd := range cmds {
+		select {
+		case <-c.quit:
+			return i, errClientClosed
+		default:
+		}
+		if c.reconnecting {
+			return i, &ConnectionError{Err: errReconnecting, Retryable: true}
+		}
+		if err := c.execLimited(ctx, cmd, nil); err != nil {
+			return i, err
+		}
+	}
+	return len(cmds), nil
+}
+
+// notifyReg returns the registry entry of scope, adding an inactive one.
+func (c *Client) notifyReg(scope NotifyScope) *NotifyRegistration {
+	if reg := c.findNotifyReg(scope); reg != nil {
+		return reg
+	}
+	reg := &NotifyRegistration{Scope: scope}
+	c.notifyRegs = append(c.notifyRegs, reg)
+	return reg
+}
+
+func (c *Client) findNotifyReg(scope NotifyScope) *NotifyRegistration {
+	for _, reg := range c.notifyRegs {
+		if reg.Scope == scope {
+			return reg
+		}
+	}
+	return nil
+}
+
+// pruneNotify drops inactive entries and reports whether there were any.
+func (c *Client) pruneNotify() bool {
+	kept := c.notifyRegs[:0]
+	for _, reg := range c.notifyRegs {
+		if reg.active() {
+			kept = append(kept, reg)
+		}
+	}
+	pruned := len(kept) != len(c.notifyRegs)
+	clear(c.notifyRegs[len(kept):])
+	c.notifyRegs = kept
+	return pruned
+}
+
+// syncNotifySession stores the active scopes for replay after a reconnect.
+func (c *Client) syncNotifySession() {
+	cmds := make([]string, 0, len(c.notifyRegs))
+	for _, reg := range c.notifyRegs {
+		cmds = append(cmds, reg.Scope.command())
+	}
+	c.updateSession(func(s *sessionState) {
+		s.notify = cmds
+	})
+}
@@ -0,0 +1,159 @@
+package ts3
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jkesh/ts3-go/v2/ts3/models"
+)
+
+func TestNotifyRegistryReappliedAfterUse(t *testing.T) {
+	cmdCh := make(chan string, 32)
+	conn := newMockServerConn(t, func(cmd string) []string {
+		cmdCh <- cmd
+		return []string{"error id=0 msg=ok"}
+	})
+
+	client, err := NewClientFromConn(conn, Config{})
+	if err != nil {
+		t.Fatalf("NewClientFromConn failed: %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	sent := func() string {
+		var cmds []string
+		for len(cmdCh) > 0 {
+			cmds = append(cmds, <-cmdCh)
+		}
+		return strings.Join(cmds, "\n")
+	}
+
+	if err := client.RegisterServerEvents(ctx); err != nil {
+		t.Fatalf("RegisterServerEvents failed: %v", err)
+	}
+	if err := client.RegisterChannelEvents(ctx, 5); err != nil {
+		t.Fatalf("RegisterChannelEvents failed: %v", err)
+	}
+	// Already active: no duplicate registration.
+	if err := client.RegisterServerEvents(ctx); err != nil {
+		t.Fatalf("RegisterServerEvents failed: %v", err)
+	}
+	if _, err := client.OnClientEnterView(ctx, func(models.ClientEnterView) {}); err != nil {
+		t.Fatalf("OnClientEnterView failed: %v", err)
+	}
+	want := "servernotifyregister event=server\nservernotifyregister event=channel id=5"
+	if got := sent(); got != want {
+		t.Fatalf("unexpected registrations:\n got=%q\nwant=%q", got, want)
+	}
+
+	regs := client.NotifyRegistrations()
+	if len(regs) != 2 ||
+		regs[0] != (NotifyRegistration{Scope: NotifyScope{Event: NotifyServer}, Handlers: 1, Pinned: true, Applied: true}) ||
+		regs[1] != (NotifyRegistration{Scope: NotifyScope{Event: NotifyChannel, ChannelID: 5}, Pinned: true, Applied: true}) {
+		t.Fatalf("unexpected registry: %+v", regs)
+	}
+
+	if err := client.Use(ctx, 2); err != nil {
+		t.Fatalf("Use failed: %v", err)
+	}
+	want = "use sid=2\nservernotifyregister event=server\nservernotifyregister event=channel id=5"
+	if got := sent(); got != want {
+		t.Fatalf("unexpected commands after use:\n got=%q\nwant=%q", got, want)
+	}
+
+	if err := client.UnregisterNotify(ctx); err != nil {
+		t.Fatalf("UnregisterNotify failed: %v", err)
+	}
+	if regs := client.NotifyRegistrations(); len(regs) != 0 {
+		t.Fatalf("registry not cleared: %+v", regs)
+	}
+	if err := client.UseByPort(ctx, 9987); err != nil {
+		t.Fatalf("UseByPort failed: %v", err)
+	}
+	want = "servernotifyunregister\nuse port=9987"
+	if got := sent(); got != want {
+		t.Fatalf("unexpected commands:\n got=%q\nwant=%q", got, want)
+	}
+}
+
+func TestNotifyReleaseMarksFailedReapply(t *testing.T) {
+	var mu sync.Mutex
+	var cmds []string
+	failServer := false
+	conn := newMockServerConn(t, func(cmd string) []string {
+		mu.Lock()
+		defer mu.Unlock()
+		cmds = append(cmds, cmd)
+		if failServer && cmd == scopeServer.command() {
+			return []string{"error id=2568 msg=insufficient\\sclient\\spermissions"}
+		}
+		return []string{"error id=0 msg=ok"}
+	})
+
+	client, err := NewClientFromConn(conn, Config{})
+	if err != nil {
+		t.Fatalf("NewClientFromConn failed: %v", err)
+	}
+	defer client.Close()
+	logger := &captureLogger{lines: make(chan string, 4)}
+	client.SetLogger(logger)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if err := client.RegisterServerEvents(ctx); err != nil {
+		t.Fatalf("RegisterServerEvents failed: %v", err)
+	}
+	if err := client.RegisterTokenEvents(ctx); err != nil {
+		t.Fatalf("RegisterTokenEvents failed: %v", err)
+	}
+	sub, err := client.OnText(ctx, func(models.TextMessage) {})
+	if err != nil {
+		t.Fatalf("OnText failed: %v", err)
+	}
+
+	mu.Lock()
+	cmds, failServer = nil, true
+	mu.Unlock()
+	if err := sub.Close(); err == nil {
+		t.Fatalf("expected re-apply error")
+	}
+
+	mu.Lock()
+	got := strings.Join(cmds, "\n")
+	mu.Unlock()
+	want := "servernotifyunregister\n" + scopeServer.command()
+	if got != want {
+		t.Fatalf("unexpected commands:\n got=%q\nwant=%q", got, want)
+	}
+	select {
+	case line := <-logger.lines:
+		if !strings.Contains(line, "event=server") {
+			t.Fatalf("unexpected log line: %q", line)
+		}
+	default:
+		t.Fatalf("failed re-apply not logged")
+	}
+
+	regs := client.NotifyRegistrations()
+	if len(regs) != 2 || regs[0].Applied || regs[1].Applied {
+		t.Fatalf("failed entries still marked applied: %+v", regs)
+	}
+
+	// The next registration that needs the scope retries it.
+	mu.Lock()
+	cmds, failServer = nil, false
+	mu.Unlock()
+	if err := client.RegisterServerEvents(ctx); err != nil {
+		t.Fatalf("RegisterServerEvents failed: %v", err)
+	}
+	if regs := client.NotifyRegistrations(); !regs[0].Applied {
+		t.Fatalf("scope not applied again: %+v", regs)
+	}
+}
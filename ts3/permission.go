@@ -4,7 +4,7 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/jkesh/ts3-go/ts3/models"
+	"github.com/jkesh/ts3-go/v2/ts3/models"
 )
 
 // --- 频道权限 (Channel Permissions) ---
@@ -113,16 +113,7 @@ func (c *Client) ClientDelPerm(ctx context.Context, cldbid int, permName string)
 
 // PermissionList 返回实例上全部权限定义。
 func (c *Client) PermissionList(ctx context.Context) ([]models.PermissionEntry, error) {
-	resp, err := c.Exec(ctx, "permissionlist")
-	if err != nil {
-		return nil, err
-	}
-
-	var out []models.PermissionEntry
-	if err := NewDecoder().Decode(resp, &out); err != nil {
-		return nil, err
-	}
-	return out, nil
+	return QueryAll[models.PermissionEntry](ctx, c, "permissionlist")
 }
 
 // ServerGroupPermList 返回服务器组权限。
@@ -133,16 +124,7 @@ func (c *Client) ServerGroupPermList(ctx context.Context, sgid int, includePermS
 		cmd += " -permsid"
 	}
 
-	resp, err := c.Exec(ctx, cmd)
-	if err != nil {
-		return nil, err
-	}
-
-	var out []models.PermissionEntry
-	if err := NewDecoder().Decode(resp, &out); err != nil {
-		return nil, err
-	}
-	return out, nil
+	return QueryAll[models.PermissionEntry](ctx, c, cmd)
 }
 
 // ChannelGroupPermList 返回频道组权限。
@@ -153,16 +135,7 @@ func (c *Client) ChannelGroupPermList(ctx context.Context, cgid int, cid int, in
 		cmd += " -permsid"
 	}
 
-	resp, err := c.Exec(ctx, cmd)
-	if err != nil {
-		return nil, err
-	}
-
-	var out []models.PermissionEntry
-	if err := NewDecoder().Decode(resp, &out); err != nil {
-		return nil, err
-	}
-	return out, nil
+	return QueryAll[models.PermissionEntry](ctx, c, cmd)
 }
 
 // ChannelPermList 返回频道权限。
@@ -172,16 +145,7 @@ func (c *Client) ChannelPermList(ctx context.Context, cid int, includePermSID bo
 		cmd += " -permsid"
 	}
 
-	resp, err := c.Exec(ctx, cmd)
-	if err != nil {
-		return nil, err
-	}
-
-	var out []models.PermissionEntry
-	if err := NewDecoder().Decode(resp, &out); err != nil {
-		return nil, err
-	}
-	return out, nil
+	return QueryAll[models.PermissionEntry](ctx, c, cmd)
 }
 
 // ClientPermList 返回客户端数据库账号的特殊权限。
@@ -191,14 +155,5 @@ func (c *Client) ClientPermList(ctx context.Context, cldbid int, includePermSID
 		cmd += " -permsid"
 	}
 
-	resp, err := c.Exec(ctx, cmd)
-	if err != nil {
-		return nil, err
-	}
-
-	var out []models.PermissionEntry
-	if err := NewDecoder().Decode(resp, &out); err != nil {
-		return nil, err
-	}
-	return out, nil
+	return QueryAll[models.PermissionEntry](ctx, c, cmd)
 }
@@ -0,0 +1,517 @@
+package ts3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultPoolSize                = 4
+	defaultPoolHealthCheckInterval = 30 * time.Second
+)
+
+var (
+	errPoolClosed             = errors.New("ts3: pool closed")
+	errPoolNotifyUnsupported  = errors.New("ts3: event notifications are not supported on a pool")
+	errPoolSessionUnsupported = errors.New("ts3: command is not supported on a pool")
+)
+
+// PoolConfig configures a Pool of raw/SSH ServerQuery sessions.
+type PoolConfig struct {
+	// Config is used to open sessions with NewClient when Dial is nil.
+	Config Config
+	// Dial opens one new session, e.g. with NewSSHClientWithConfig.
+	// Login, server selection and nickname are applied by the pool.
+	Dial func(ctx context.Context) (*Client, error)
+
+	Username   string
+	Password   string
+	ServerID   int
+	ServerPort int
+	// Nickname is the base nickname. Sessions after the first one get a
+	// numeric suffix, because TeamSpeak requires unique nicknames. A closed
+	// session's suffix is reused by the next new session.
+	Nickname string
+
+	// Size is the maximum number of open sessions. Default: 4.
+	Size int
+	// IdleTimeout closes sessions that were not used for this long.
+	// 0 keeps idle sessions open.
+	IdleTimeout time.Duration
+	// HealthCheckInterval is how long a session may stay idle before it is
+	// checked with "whoami" when handed out again. Default: 30s.
+	HealthCheckInterval time.Duration
+	// FloodBudget limits commands per session within FloodWindow. It replaces
+	// the session's FloodLimit; 0 keeps the session's own setting.
+	// FloodWindow defaults to 3s.
+	FloodBudget int
+	FloodWindow time.Duration
+}
+
+// PoolStats is a snapshot of pool usage.
+type PoolStats struct {
+	Open  int
+	Idle  int
+	InUse int
+}
+
+// Pool holds several logged-in sessions to the same virtual server and runs
+// each command on a free one, so a slow command does not block unrelated
+// calls.
+//
+// Pool embeds a *Client, therefore the high-level methods (ClientList,
+// ChannelList, ...) are available unchanged. Event notifications are not
+// supported, because they are bound to a single session.
+type Pool struct {
+	*Client
+
+	cfg  PoolConfig
+	sem  chan struct{}
+	quit chan struct{}
+
+	mu       sync.Mutex
+	idle     []*pooledSession
+	open     int
+	slots    []bool // slots[i] is taken by the session with id i+1
+	target   poolTarget
+	closed   bool
+	closeErr error
+}
+
+// poolTarget is the session setup every pooled session has to match.
+type poolTarget struct {
+	gen        int
+	username   string
+	password   string
+	serverID   int
+	serverPort int
+	nickname   string
+}
+
+type pooledSession struct {
+	id       int
+	client   *Client
+	gen      int
+	lastUsed time.Time
+}
+
+// NewPool creates a pool and opens its first session to validate the
+// configuration.
+func NewPool(ctx context.Context, cfg PoolConfig) (*Pool, error) {
+	if cfg.Dial == nil && strings.TrimSpace(cfg.Config.Host) == "" {
+		return nil, errors.New("ts3: pool requires Dial or Config.Host")
+	}
+	if cfg.Size <= 0 {
+		cfg.Size = defaultPoolSize
+	}
+	if cfg.HealthCheckInterval <= 0 {
+		cfg.HealthCheckInterval = defaultPoolHealthCheckInterval
+	}
+
+	p := &Pool{
+		cfg:  cfg,
+		sem:  make(chan struct{}, cfg.Size),
+		quit: make(chan struct{}),
+		target: poolTarget{
+			gen:        1,
+			username:   cfg.Username,
+			password:   cfg.Password,
+			serverID:   cfg.ServerID,
+			serverPort: cfg.ServerPort,
+			nickname:   cfg.Nickname,
+		},
+	}
+	p.Client = &Client{
+		pool:   p,
+		quit:   make(chan struct{}),
+		logger: &NopLogger{},
+		state:  StateReady,
+	}
+	p.Client.events = newDispatcher(0, OverflowDefault, p.Client.quit, p.Client.logf)
+
+	s, err := p.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p.release(s, nil)
+
+	if cfg.IdleTimeout > 0 {
+		p.Client.spawn(func() { p.evictLoop(cfg.IdleTimeout) })
+	}
+	return p, nil
+}
+
+// Exec runs a raw command on a free session.
+func (p *Pool) Exec(ctx context.Context, cmd string) (string, error) {
+	return collectRows(func(onRow rowFunc) error {
+		return p.exec(ctx, cmd, nil, onRow)
+	})
+}
+
+// ExecCommand runs a Command on a free session.
+func (p *Pool) ExecCommand(ctx context.Context, cmd *Command) (string, error) {
+	if cmd == nil || cmd.name == "" {
+		return "", errEmptyCommand
+	}
+	return collectRows(func(onRow rowFunc) error {
+		return p.exec(ctx, cmd.String(), cmd, onRow)
+	})
+}
+
+func (p *Pool) exec(ctx context.Context, raw string, cmd *Command, onRow rowFunc) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	s, err := p.acquire(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = s.client.exec(ctx, raw, cmd, onRow)
+	p.release(s, err)
+	return err
+}
+
+// Login changes the credentials used by all sessions.
+func (p *Pool) Login(ctx context.Context, username, password string) error {
+	return p.retarget(ctx, func(t *poolTarget) {
+		t.username = username
+		t.password = password
+	})
+}
+
+// Use selects the virtual server for all sessions.
+func (p *Pool) Use(ctx context.Context, virtualServerID int) error {
+	return p.retarget(ctx, func(t *poolTarget) {
+		t.serverID = virtualServerID
+		t.serverPort = 0
+	})
+}
+
+// UseByPort selects the virtual server by voice port for all sessions.
+func (p *Pool) UseByPort(ctx context.Context, port int) error {
+	return p.retarget(ctx, func(t *poolTarget) {
+		t.serverID = 0
+		t.serverPort = port
+	})
+}
+
+// SetNickname changes the base nickname of all sessions.
+func (p *Pool) SetNickname(ctx context.Context, nickname string) error {
+	if strings.TrimSpace(nickname) == "" {
+		return errors.New("ts3: nickname is required")
+	}
+	return p.retarget(ctx, func(t *poolTarget) {
+		t.nickname = nickname
+	})
+}
+
+// Logout is not supported, because pooled sessions must stay logged in.
+func (p *Pool) Logout(ctx context.Context) error {
+	return errPoolSessionUnsupported
+}
+
+// retarget updates the session setup and applies it to one session right
+// away, so configuration errors are reported to the caller. The remaining
+// sessions are updated when they are handed out next.
+func (p *Pool) retarget(ctx context.Context, fn func(t *poolTarget)) error {
+	p.mu.Lock()
+	fn(&p.target)
+	p.target.gen++
+	p.mu.Unlock()
+
+	s, err := p.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	p.release(s, nil)
+	return nil
+}
+
+// Stats returns the current pool usage.
+func (p *Pool) Stats() PoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return PoolStats{
+		Open:  p.open,
+		Idle:  len(p.idle),
+		InUse: p.open - len(p.idle),
+	}
+}
+
+// Close closes all idle sessions. Sessions in use are closed when released.
+func (p *Pool) Close() error {
+	return p.close((*Client).Close)
+}
+
+// Shutdown waits for commands in progress, shuts down every session with
+// Client.Shutdown and returns after the pool's own goroutines exited.
+func (p *Pool) Shutdown(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	// Holding every slot means no session is in use.
+	for i := 0; i < cap(p.sem); i++ {
+		select {
+		case p.sem <- struct{}{}:
+		case <-ctx.Done():
+			_ = p.Close()
+			return ctx.Err()
+		}
+	}
+
+	closeErr := p.close(func(c *Client) error { return c.Shutdown(ctx) })
+	if err := waitGroupContext(ctx, &p.Client.wg); err != nil {
+		return err
+	}
+	return closeErr
+}
+
+func (p *Pool) close(closeSession func(c *Client) error) error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return p.closeErr
+	}
+	p.closed = true
+	idle := p.idle
+	p.idle = nil
+	for _, s := range idle {
+		p.freeSlotLocked(s.id)
+	}
+	p.mu.Unlock()
+
+	close(p.quit)
+	_ = p.Client.Close()
+
+	var errs []error
+	for _, s := range idle {
+		if err := closeSession(s.client); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	p.mu.Lock()
+	p.closeErr = errors.Join(errs...)
+	p.mu.Unlock()
+	return p.closeErr
+}
+
+// acquire hands out a healthy session that matches the current target.
+func (p *Pool) acquire(ctx context.Context) (*pooledSession, error) {
+	select {
+	case p.sem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-p.quit:
+		return nil, errPoolClosed
+	}
+
+	for {
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			<-p.sem
+			return nil, errPoolClosed
+		}
+		target := p.target
+		var s *pooledSession
+		var id int
+		if n := len(p.idle); n > 0 {
+			s = p.idle[n-1]
+			p.idle = p.idle[:n-1]
+		} else {
+			id = p.takeSlotLocked()
+		}
+		p.mu.Unlock()
+
+		if s == nil {
+			s, err := p.dialSession(ctx, id, target)
+			if err != nil {
+				p.mu.Lock()
+				p.freeSlotLocked(id)
+				p.mu.Unlock()
+				<-p.sem
+				return nil, err
+			}
+			return s, nil
+		}
+
+		if err := p.prepare(ctx, s, target); err != nil {
+			p.logf("ts3: pool session %d discarded: %v", s.id, err)
+			p.discard(s)
+			if ctx.Err() != nil {
+				<-p.sem
+				return nil, ctx.Err()
+			}
+			continue
+		}
+		return s, nil
+	}
+}
+
+// prepare health-checks a reused session and brings it to the target setup.
+func (p *Pool) prepare(ctx context.Context, s *pooledSession, target poolTarget) error {
+	if s.gen != target.gen {
+		return p.applyTarget(ctx, s, target)
+	}
+	if time.Since(s.lastUsed) < p.cfg.HealthCheckInterval {
+		return nil
+	}
+	_, err := s.client.Exec(ctx, "whoami")
+	return err
+}
+
+func (p *Pool) dialSession(ctx context.Context, id int, target poolTarget) (*pooledSession, error) {
+	var (
+		client *Client
+		err    error
+	)
+	if p.cfg.Dial != nil {
+		client, err = p.cfg.Dial(ctx)
+	} else {
+		client, err = NewClientContext(ctx, p.cfg.Config)
+	}
+	if err != nil {
+		return nil, err
+	}
+	client.SetLogger(p.getLogger())
+	if p.cfg.FloodBudget > 0 {
+		client.SetFloodLimit(FloodLimit{
+			Commands: p.cfg.FloodBudget,
+			Window:   p.cfg.FloodWindow,
+		})
+	}
+
+	s := &pooledSession{id: id, client: client}
+	if err := p.applyTarget(ctx, s, target); err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// applyTarget logs in, selects the server and sets the nickname.
+func (p *Pool) applyTarget(ctx context.Context, s *pooledSession, target poolTarget) error {
+	if target.username != "" {
+		if err := s.client.Login(ctx, target.username, target.password); err != nil {
+			return err
+		}
+	}
+	switch {
+	case target.serverID > 0:
+		if err := s.client.Use(ctx, target.serverID); err != nil {
+			return err
+		}
+	case target.serverPort > 0:
+		if err := s.client.UseByPort(ctx, target.serverPort); err != nil {
+			return err
+		}
+	}
+	if target.nickname != "" {
+		nickname := target.nickname
+		if s.id > 1 {
+			nickname = fmt.Sprintf("%s%d", nickname, s.id)
+		}
+		if err := s.client.SetNickname(ctx, nickname); err != nil {
+			return err
+		}
+	}
+	s.gen = target.gen
+	return nil
+}
+
+// release returns a session after use. Sessions with transport errors are
+// closed instead of being reused.
+func (p *Pool) release(s *pooledSession, execErr error) {
+	defer func() { <-p.sem }()
+
+	var connErr *ConnectionError
+	if errors.As(execErr, &connErr) || errors.Is(execErr, errClientClosed) {
+		p.discard(s)
+		return
+	}
+
+	s.lastUsed = time.Now()
+	p.mu.Lock()
+	if p.closed {
+		p.freeSlotLocked(s.id)
+		p.mu.Unlock()
+		_ = s.client.Close()
+		return
+	}
+	p.idle = append(p.idle, s)
+	p.mu.Unlock()
+}
+
+// discard closes a session and frees its slot.
+func (p *Pool) discard(s *pooledSession) {
+	p.mu.Lock()
+	p.freeSlotLocked(s.id)
+	p.mu.Unlock()
+	_ = s.client.Close()
+}
+
+// takeSlotLocked reserves the lowest free session id, so nicknames stay
+// within Bot..Bot<Size> while sessions come and go.
+func (p *Pool) takeSlotLocked() int {
+	p.open++
+	for i, taken := range p.slots {
+		if !taken {
+			p.slots[i] = true
+			return i + 1
+		}
+	}
+	p.slots = append(p.slots, true)
+	return len(p.slots)
+}
+
+func (p *Pool) freeSlotLocked(id int) {
+	p.open--
+	p.slots[id-1] = false
+}
+
+// evictLoop closes sessions that stayed idle longer than timeout.
+func (p *Pool) evictLoop(timeout time.Duration) {
+	ticker := time.NewTicker(timeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.evictIdle(timeout)
+		case <-p.quit:
+			return
+		}
+	}
+}
+
+func (p *Pool) evictIdle(timeout time.Duration) {
+	cutoff := time.Now().Add(-timeout)
+
+	p.mu.Lock()
+	var expired []*pooledSession
+	kept := p.idle[:0]
+	for _, s := range p.idle {
+		if s.lastUsed.Before(cutoff) {
+			expired = append(expired, s)
+			continue
+		}
+		kept = append(kept, s)
+	}
+	p.idle = kept
+	for _, s := range expired {
+		p.freeSlotLocked(s.id)
+	}
+	p.mu.Unlock()
+
+	for _, s := range expired {
+		_ = s.client.Close()
+	}
+}
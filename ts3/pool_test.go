@@ -0,0 +1,131 @@
+package ts3
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPoolRunsCommandsOnSeparateSessions(t *testing.T) {
+	slowStarted := make(chan struct{})
+	releaseSlow := make(chan struct{})
+	srv := newMockTCPServer(t, func(cmd string) []string {
+		switch {
+		case strings.HasPrefix(cmd, "clientdblist"):
+			close(slowStarted)
+			<-releaseSlow
+			return []string{"cldbid=1 client_nickname=Alice", "error id=0 msg=ok"}
+		case cmd == "channellist":
+			return []string{"cid=1 channel_name=Lobby|cid=2 channel_name=AFK", "error id=0 msg=ok"}
+		}
+		return []string{"error id=0 msg=ok"}
+	})
+	host, port := srv.hostPort(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	pool, err := NewPool(ctx, PoolConfig{
+		Config:   Config{Host: host, Port: port},
+		Username: "serveradmin",
+		Password: "secret",
+		ServerID: 1,
+		Nickname: "Bot",
+		Size:     2,
+	})
+	if err != nil {
+		t.Fatalf("NewPool failed: %v", err)
+	}
+	defer pool.Close()
+
+	slowDone := make(chan error, 1)
+	go func() {
+		_, err := pool.ClientDBList(ctx, 0, 100)
+		slowDone <- err
+	}()
+	<-slowStarted
+
+	channels, err := pool.ChannelList(ctx)
+	if err != nil {
+		t.Fatalf("ChannelList failed: %v", err)
+	}
+	if len(channels) != 2 || channels[1].Name != "AFK" {
+		t.Fatalf("unexpected channels: %+v", channels)
+	}
+
+	close(releaseSlow)
+	if err := <-slowDone; err != nil {
+		t.Fatalf("ClientDBList failed: %v", err)
+	}
+
+	if stats := pool.Stats(); stats.Open != 2 || stats.InUse != 0 {
+		t.Fatalf("unexpected pool stats: %+v", stats)
+	}
+
+	setup := srv.commands(1)
+	want := []string{
+		"login client_login_name=serveradmin client_login_password=secret",
+		"use sid=1",
+		"clientupdate client_nickname=Bot2",
+	}
+	if len(setup) < len(want) || strings.Join(setup[:3], "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected session setup: %q", setup)
+	}
+}
+
+func TestPoolHealthCheckReplacesDeadSession(t *testing.T) {
+	srv := newMockTCPServer(t, func(cmd string) []string {
+		return []string{"error id=0 msg=ok"}
+	})
+	host, port := srv.hostPort(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	pool, err := NewPool(ctx, PoolConfig{
+		Config:              Config{Host: host, Port: port},
+		Nickname:            "Bot",
+		Size:                1,
+		HealthCheckInterval: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewPool failed: %v", err)
+	}
+	defer pool.Close()
+
+	_ = srv.conn(0).Close()
+	time.Sleep(20 * time.Millisecond)
+
+	if _, err := pool.Exec(ctx, "version"); err != nil {
+		t.Fatalf("Exec after dead session failed: %v", err)
+	}
+	got := srv.commands(1)
+	if len(got) == 0 || got[len(got)-1] != "version" {
+		t.Fatalf("command was not sent on a new session: %q", got)
+	}
+	// The replacement takes over the free slot and its nickname.
+	if got[0] != "clientupdate client_nickname=Bot" {
+		t.Fatalf("unexpected replacement setup: %q", got)
+	}
+}
+
+func TestPoolRejectsNotifications(t *testing.T) {
+	srv := newMockTCPServer(t, func(cmd string) []string {
+		return []string{"error id=0 msg=ok"}
+	})
+	host, port := srv.hostPort(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	pool, err := NewPool(ctx, PoolConfig{Config: Config{Host: host, Port: port}})
+	if err != nil {
+		t.Fatalf("NewPool failed: %v", err)
+	}
+	defer pool.Close()
+
+	if err := pool.RegisterServerEvents(ctx); err == nil {
+		t.Fatalf("expected RegisterServerEvents to fail on a pool")
+	}
+}
@@ -17,20 +17,32 @@ var ts3Escaper = strings.NewReplacer(
 	"\v", "\\v",
 )
 
-// ts3Unescaper 用于接收响应时的反转义 (TS3 Server -> Go string)
-var ts3Unescaper = strings.NewReplacer(
-	"\\\\", "\\",
-	"\\/", "/",
-	"\\s", " ",
-	"\\p", "|",
-	"\\a", "\a",
-	"\\b", "\b",
-	"\\f", "\f",
-	"\\n", "\n",
-	"\\r", "\r",
-	"\\t", "\t",
-	"\\v", "\v",
-)
+// unescapeByte 返回转义序列 \c 对应的字符 (TS3 Server -> Go string)
+func unescapeByte(c byte) (byte, bool) {
+	switch c {
+	case '\\', '/':
+		return c, true
+	case 's':
+		return ' ', true
+	case 'p':
+		return '|', true
+	case 'a':
+		return '\a', true
+	case 'b':
+		return '\b', true
+	case 'f':
+		return '\f', true
+	case 'n':
+		return '\n', true
+	case 'r':
+		return '\r', true
+	case 't':
+		return '\t', true
+	case 'v':
+		return '\v', true
+	}
+	return 0, false
+}
 
 // Escape 将普通字符串转义为 TS3 协议格式
 func Escape(s string) string {
@@ -39,5 +51,140 @@ func Escape(s string) string {
 
 // Unescape 将 TS3 协议格式字符串还原为普通字符串
 func Unescape(s string) string {
-	return ts3Unescaper.Replace(s)
+	if strings.IndexByte(s, '\\') < 0 {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			if c, ok := unescapeByte(s[i+1]); ok {
+				b.WriteByte(c)
+				i++
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// appendUnescaped 将 s 反转义后追加到 dst
+func appendUnescaped(dst []byte, s string) []byte {
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			if c, ok := unescapeByte(s[i+1]); ok {
+				dst = append(dst, c)
+				i++
+				continue
+			}
+		}
+		dst = append(dst, s[i])
+	}
+	return dst
+}
+
+// responseField 是响应行中的一个 key=value，value 已反转义
+type responseField struct {
+	key   string
+	value string
+}
+
+// tokenizedResponse 是切分后的响应：第 i 行为 fields[ends[i-1]:ends[i]]
+type tokenizedResponse struct {
+	fields []responseField
+	ends   []int
+}
+
+func (r tokenizedResponse) len() int {
+	return len(r.ends)
+}
+
+func (r tokenizedResponse) row(i int) []responseField {
+	start := 0
+	if i > 0 {
+		start = r.ends[i-1]
+	}
+	return r.fields[start:r.ends[i]]
+}
+
+func (r tokenizedResponse) rowMap(i int) map[string]string {
+	row := r.row(i)
+	m := make(map[string]string, len(row))
+	for _, kv := range row {
+		m[kv.key] = kv.value
+	}
+	return m
+}
+
+// tokenizeResponse 单次遍历切分响应的行与字段。键和未转义的值直接引用
+// response；需要反转义的值写入同一块缓冲区，最后只分配一次字符串。空行被忽略。
+func tokenizeResponse(response string) tokenizedResponse {
+	type escaped struct{ field, start, end int }
+	var (
+		arena   []byte
+		pending []escaped
+	)
+
+	resp := tokenizedResponse{
+		fields: make([]responseField, 0, strings.Count(response, " ")+1),
+		ends:   make([]int, 0, strings.Count(response, "|")+1),
+	}
+	endRow := func() {
+		if n := len(resp.fields); n > 0 && (len(resp.ends) == 0 || resp.ends[len(resp.ends)-1] < n) {
+			resp.ends = append(resp.ends, n)
+		}
+	}
+
+	for i := 0; i < len(response); {
+		switch c := response[i]; {
+		case c == '|':
+			endRow()
+			i++
+		case isSpaceByte(c):
+			i++
+		default:
+			start, eq, esc := i, -1, false
+			for ; i < len(response); i++ {
+				c := response[i]
+				if c == '|' || isSpaceByte(c) {
+					break
+				}
+				if c == '=' && eq < 0 {
+					eq = i
+				} else if c == '\\' && eq >= 0 {
+					esc = true
+				}
+			}
+
+			if eq < 0 {
+				resp.fields = append(resp.fields, responseField{key: response[start:i]})
+				continue
+			}
+			kv := responseField{key: response[start:eq], value: response[eq+1 : i]}
+			if esc {
+				from := len(arena)
+				arena = appendUnescaped(arena, kv.value)
+				pending = append(pending, escaped{field: len(resp.fields), start: from, end: len(arena)})
+			}
+			resp.fields = append(resp.fields, kv)
+		}
+	}
+	endRow()
+
+	if len(pending) > 0 {
+		unescaped := string(arena)
+		for _, p := range pending {
+			resp.fields[p.field].value = unescaped[p.start:p.end]
+		}
+	}
+	return resp
+}
+
+func isSpaceByte(c byte) bool {
+	switch c {
+	case ' ', '\t', '\n', '\r', '\v', '\f':
+		return true
+	}
+	return false
 }
@@ -0,0 +1,66 @@
+package ts3
+
+import (
+	"context"
+	"errors"
+)
+
+// Execer runs ServerQuery commands. *Client and *Pool implement it.
+type Execer interface {
+	Exec(ctx context.Context, cmd string) (string, error)
+	ExecCommand(ctx context.Context, cmd *Command) (string, error)
+}
+
+// Query is a raw command line or a Command.
+type Query interface {
+	string | *Command
+}
+
+// QueryOne runs cmd and decodes the first response row into a T:
+//
+//	info, err := ts3.QueryOne[models.VersionInfo](ctx, client, "version")
+//
+// An empty response yields a zero T.
+func QueryOne[T any, Q Query](ctx context.Context, c Execer, cmd Q) (*T, error) {
+	resp, err := execQuery(ctx, c, cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	var out T
+	if err := NewDecoder().Decode(resp, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// QueryAll runs cmd and decodes every response row into a T:
+//
+//	bans, err := ts3.QueryAll[models.BanEntry](ctx, client, ts3.NewCommand("banlist"))
+//
+// ErrDatabaseEmptyResult is reported as an empty slice, not as an error.
+func QueryAll[T any, Q Query](ctx context.Context, c Execer, cmd Q) ([]T, error) {
+	resp, err := execQuery(ctx, c, cmd)
+	if err != nil {
+		var qerr *Error
+		if errors.As(err, &qerr) && qerr.Is(ErrDatabaseEmptyResult) {
+			return []T{}, nil
+		}
+		return nil, err
+	}
+
+	var out []T
+	if err := NewDecoder().Decode(resp, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func execQuery[Q Query](ctx context.Context, c Execer, cmd Q) (string, error) {
+	switch q := any(cmd).(type) {
+	case *Command:
+		return c.ExecCommand(ctx, q)
+	default:
+		return c.Exec(ctx, q.(string))
+	}
+}
@@ -0,0 +1,69 @@
+package ts3
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jkesh/ts3-go/v2/ts3/models"
+)
+
+func TestQueryAllAndQueryOne(t *testing.T) {
+	cmdCh := make(chan string, 4)
+	conn := newMockServerConn(t, func(cmd string) []string {
+		cmdCh <- cmd
+		switch {
+		case cmd == "banlist":
+			return []string{"error id=1281 msg=database\\sempty\\sresult\\sset"}
+		case cmd == "channellist -topic":
+			return []string{
+				"cid=1 channel_name=Lobby channel_topic=Welcome|cid=2 channel_name=AFK",
+				"error id=0 msg=ok",
+			}
+		case cmd == "version":
+			return []string{"version=3.13.7 build=1655727713 platform=Linux", "error id=0 msg=ok"}
+		default:
+			return []string{"error id=256 msg=command\\snot\\sfound"}
+		}
+	})
+
+	client, err := NewClientFromConn(conn, Config{})
+	if err != nil {
+		t.Fatalf("NewClientFromConn failed: %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	bans, err := client.BanList(ctx)
+	if err != nil {
+		t.Fatalf("BanList failed: %v", err)
+	}
+	if bans == nil || len(bans) != 0 {
+		t.Fatalf("expected empty non-nil slice, got %#v", bans)
+	}
+
+	channels, err := QueryAll[models.Channel](ctx, client, NewCommand("channellist").Flag(OptTopic))
+	if err != nil {
+		t.Fatalf("QueryAll failed: %v", err)
+	}
+	if len(channels) != 2 || channels[0].Topic != "Welcome" || channels[1].Name != "AFK" {
+		t.Fatalf("unexpected channels: %+v", channels)
+	}
+
+	version, err := QueryOne[models.VersionInfo](ctx, client, "version")
+	if err != nil {
+		t.Fatalf("QueryOne failed: %v", err)
+	}
+	if version.Version != "3.13.7" || version.Platform != "Linux" {
+		t.Fatalf("unexpected version: %+v", version)
+	}
+
+	_, err = QueryAll[models.Channel](ctx, client, "unknowncommand")
+	var qerr *Error
+	if !errors.As(err, &qerr) || !qerr.Is(ErrCommandNotFound) {
+		t.Fatalf("expected command not found error, got %v", err)
+	}
+}
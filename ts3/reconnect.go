@@ -0,0 +1,246 @@
+package ts3
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"time"
+)
+
+const (
+	defaultReconnectInitialBackoff = time.Second
+	defaultReconnectMaxBackoff     = 30 * time.Second
+	defaultReconnectMultiplier     = 2.0
+)
+
+// dialFunc opens a new raw/SSH transport for the same endpoint.
+type dialFunc func(ctx context.Context) (io.ReadWriteCloser, error)
+
+// ReconnectPolicy controls how a raw/SSH client restores a dropped connection.
+//
+// After a successful redial the client replays login, virtual server
+// selection, nickname and active servernotifyregister commands. Handlers added
+// with Register are kept.
+type ReconnectPolicy struct {
+	// InitialBackoff is the delay before the first redial. Default: 1s.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between attempts. Default: 30s.
+	MaxBackoff time.Duration
+	// Multiplier grows the delay after each failed attempt. Default: 2.
+	Multiplier float64
+	// Jitter randomizes every delay by up to ±Jitter (0..1) of its value.
+	Jitter float64
+	// MaxAttempts limits redials per disconnect. 0 means unlimited.
+	// The client is closed when all attempts failed.
+	MaxAttempts int
+}
+
+// backoff returns the delay before the given attempt (starting at 1).
+func (p ReconnectPolicy) backoff(attempt int) time.Duration {
+	initial := p.InitialBackoff
+	if initial <= 0 {
+		initial = defaultReconnectInitialBackoff
+	}
+	maxBackoff := p.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = defaultReconnectMaxBackoff
+	}
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = defaultReconnectMultiplier
+	}
+
+	d := float64(initial)
+	for i := 1; i < attempt && d < float64(maxBackoff); i++ {
+		d *= multiplier
+	}
+	if d > float64(maxBackoff) {
+		d = float64(maxBackoff)
+	}
+
+	if p.Jitter > 0 {
+		jitter := p.Jitter
+		if jitter > 1 {
+			jitter = 1
+		}
+		d += d * jitter * (rand.Float64()*2 - 1)
+	}
+	return time.Duration(d)
+}
+
+// sessionState records what has to be replayed on a new connection.
+type sessionState struct {
+	loggedIn      bool
+	loginName     string
+	loginPassword string
+	serverID      int
+	serverPort    int
+	nickname      string
+	notify        []string
+}
+
+func (s *sessionState) replayCommands() []string {
+	cmds := make([]string, 0, 3+len(s.notify))
+	if s.loggedIn {
+		cmds = append(cmds, fmt.Sprintf(
+			"login client_login_name=%s client_login_password=%s",
+			Escape(s.loginName),
+			Escape(s.loginPassword),
+		))
+	}
+	switch {
+	case s.serverID > 0:
+		cmds = append(cmds, fmt.Sprintf("use sid=%d", s.serverID))
+	case s.serverPort > 0:
+		cmds = append(cmds, fmt.Sprintf("use port=%d", s.serverPort))
+	}
+	if s.nickname != "" {
+		cmds = append(cmds, "clientupdate client_nickname="+Escape(s.nickname))
+	}
+	return append(cmds, s.notify...)
+}
+
+// updateSession applies fn to the recorded session state.
+func (c *Client) updateSession(fn func(s *sessionState)) {
+	c.mu.Lock()
+	fn(&c.session)
+	c.mu.Unlock()
+}
+
+// connLost is called by readLoop when its connection ended.
+func (c *Client) connLost(gen uint64, cause error) {
+	select {
+	case <-c.quit:
+		return
+	default:
+	}
+	if c.isShuttingDown() {
+		c.setState(StateClosed)
+		return
+	}
+
+	c.mu.Lock()
+	if gen != c.connGen || c.reconnecting {
+		c.mu.Unlock()
+		return
+	}
+	if c.reconnect != nil {
+		c.reconnecting = true
+	}
+	c.mu.Unlock()
+
+	if c.reconnect != nil {
+		c.setState(StateConnecting)
+	} else {
+		c.setState(StateClosed)
+	}
+
+	if cause == nil {
+		cause = errConnClosed
+	}
+	c.logf("ts3: connection lost: %v", cause)
+	if c.onDisconnect != nil {
+		c.onDisconnect(cause)
+	}
+
+	if c.reconnect != nil {
+		policy := *c.reconnect
+		c.spawn(func() { c.reconnectLoop(policy) })
+	}
+}
+
+// reconnectLoop redials with backoff until the session is restored, the
+// attempts are exhausted or the client is closed.
+func (c *Client) reconnectLoop(policy ReconnectPolicy) {
+	for attempt := 1; policy.MaxAttempts <= 0 || attempt <= policy.MaxAttempts; attempt++ {
+		timer := time.NewTimer(policy.backoff(attempt))
+		select {
+		case <-timer.C:
+		case <-c.quit:
+			timer.Stop()
+			return
+		}
+
+		if err := c.redial(); err != nil {
+			c.logf("ts3: reconnect attempt %d failed: %v", attempt, err)
+			continue
+		}
+
+		c.logf("ts3: reconnected after %d attempt(s)", attempt)
+		if c.onReconnect != nil {
+			c.onReconnect(attempt)
+		}
+		return
+	}
+
+	c.logf("ts3: giving up reconnecting after %d attempts", policy.MaxAttempts)
+	_ = c.Close()
+}
+
+// redial opens a new connection, swaps it in and replays the session.
+func (c *Client) redial() error {
+	dialCtx, cancelDial := context.WithTimeout(context.Background(), c.timeout)
+	defer cancelDial()
+
+	conn, err := c.dial(dialCtx)
+	if err != nil {
+		return err
+	}
+
+	scanner := newLineScanner(conn, c.maxLineSize)
+	if err := readHandshakeContext(dialCtx, conn, scanner); err != nil {
+		_ = conn.Close()
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.connMu.Lock()
+	select {
+	case <-c.quit:
+		c.connMu.Unlock()
+		_ = conn.Close()
+		return errClientClosed
+	default:
+	}
+	old := c.conn
+	c.conn = conn
+	c.connMu.Unlock()
+	if old != nil {
+		_ = old.Close()
+	}
+
+	c.connGen++
+	c.poisoned = false
+	c.touch()
+	c.scanner = scanner
+	c.cmdResChan = make(chan string, defaultCmdBufSize)
+	c.errorChan = make(chan error, 1)
+	gen, cmdCh, errCh := c.connGen, c.cmdResChan, c.errorChan
+	c.spawn(func() { c.readLoop(gen, scanner, cmdCh, errCh) })
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	for _, cmd := range c.session.replayCommands() {
+		if err := c.execLimited(ctx, cmd, nil); err != nil {
+			_ = conn.Close()
+			return fmt.Errorf("ts3: restore session (%s): %w", commandName(cmd), err)
+		}
+	}
+
+	c.reconnecting = false
+	c.setState(StateReady)
+	return nil
+}
+
+// commandName returns the first word of a raw command, which is safe to log.
+func commandName(cmd string) string {
+	for i := 0; i < len(cmd); i++ {
+		if cmd[i] == ' ' {
+			return cmd[:i]
+		}
+	}
+	return cmd
+}
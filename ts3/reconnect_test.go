@@ -0,0 +1,283 @@
+package ts3
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mockTCPServer is a ServerQuery listener that records commands per connection.
+type mockTCPServer struct {
+	ln      net.Listener
+	handler func(cmd string) []string
+
+	mu    sync.Mutex
+	conns []net.Conn
+	cmds  [][]string
+}
+
+func newMockTCPServer(t *testing.T, handler func(cmd string) []string) *mockTCPServer {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	s := &mockTCPServer{ln: ln, handler: handler}
+	t.Cleanup(s.close)
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			idx := len(s.conns)
+			s.conns = append(s.conns, conn)
+			s.cmds = append(s.cmds, nil)
+			s.mu.Unlock()
+			go s.serve(idx, conn)
+		}
+	}()
+	return s
+}
+
+func (s *mockTCPServer) serve(idx int, conn net.Conn) {
+	defer conn.Close()
+
+	writer := bufio.NewWriter(conn)
+	_, _ = writer.WriteString("TS3\nWelcome to TeamSpeak 3 ServerQuery\n")
+	_ = writer.Flush()
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		cmd := strings.TrimSpace(scanner.Text())
+		s.mu.Lock()
+		s.cmds[idx] = append(s.cmds[idx], cmd)
+		s.mu.Unlock()
+		for _, line := range s.handler(cmd) {
+			_, _ = writer.WriteString(line + "\n")
+		}
+		_ = writer.Flush()
+	}
+}
+
+func (s *mockTCPServer) hostPort(t *testing.T) (string, int) {
+	t.Helper()
+	host, portStr, err := net.SplitHostPort(s.ln.Addr().String())
+	if err != nil {
+		t.Fatalf("split host port failed: %v", err)
+	}
+	port, _ := strconv.Atoi(portStr)
+	return host, port
+}
+
+func (s *mockTCPServer) conn(idx int) net.Conn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conns[idx]
+}
+
+func (s *mockTCPServer) commands(idx int) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.cmds[idx]...)
+}
+
+func (s *mockTCPServer) close() {
+	_ = s.ln.Close()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.conns {
+		_ = conn.Close()
+	}
+}
+
+func TestClientReconnectRestoresSession(t *testing.T) {
+	srv := newMockTCPServer(t, func(cmd string) []string {
+		return []string{"error id=0 msg=ok"}
+	})
+	host, port := srv.hostPort(t)
+
+	disconnected := make(chan error, 1)
+	reconnected := make(chan int, 1)
+	client, err := NewClient(Config{
+		Host:      host,
+		Port:      port,
+		Reconnect: &ReconnectPolicy{InitialBackoff: 200 * time.Millisecond},
+		OnDisconnect: func(err error) {
+			disconnected <- err
+		},
+		OnReconnect: func(attempt int) {
+			reconnected <- attempt
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := client.Login(ctx, "serveradmin", "pass word"); err != nil {
+		t.Fatalf("Login failed: %v", err)
+	}
+	if err := client.Use(ctx, 1); err != nil {
+		t.Fatalf("Use failed: %v", err)
+	}
+	if err := client.SetNickname(ctx, "Bot"); err != nil {
+		t.Fatalf("SetNickname failed: %v", err)
+	}
+	if err := client.RegisterServerEvents(ctx); err != nil {
+		t.Fatalf("RegisterServerEvents failed: %v", err)
+	}
+
+	_ = srv.conn(0).Close()
+
+	select {
+	case <-disconnected:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("OnDisconnect was not called")
+	}
+
+	if _, err := client.Exec(ctx, "whoami"); !IsRetryable(err) {
+		t.Fatalf("expected retryable error while reconnecting, got: %v", err)
+	}
+
+	select {
+	case attempt := <-reconnected:
+		if attempt != 1 {
+			t.Fatalf("unexpected reconnect attempt: %d", attempt)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("OnReconnect was not called")
+	}
+
+	want := []string{
+		"login client_login_name=serveradmin client_login_password=pass\\sword",
+		"use sid=1",
+		"clientupdate client_nickname=Bot",
+		"servernotifyregister event=server",
+	}
+	got := srv.commands(1)
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected replayed commands:\ngot:  %q\nwant: %q", got, want)
+	}
+
+	if _, err := client.Exec(ctx, "whoami"); err != nil {
+		t.Fatalf("Exec after reconnect failed: %v", err)
+	}
+}
+
+func TestClientRedialsAfterProtocolSyncLost(t *testing.T) {
+	srv := newMockTCPServer(t, func(cmd string) []string {
+		if cmd == "hang" {
+			return nil
+		}
+		return []string{"error id=0 msg=ok"}
+	})
+	host, port := srv.hostPort(t)
+
+	reconnected := make(chan int, 1)
+	client, err := NewClient(Config{
+		Host:        host,
+		Port:        port,
+		DrainGrace:  100 * time.Millisecond,
+		Reconnect:   &ReconnectPolicy{InitialBackoff: 50 * time.Millisecond},
+		OnReconnect: func(attempt int) { reconnected <- attempt },
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = client.Exec(ctx, "hang")
+	if !errors.Is(err, ErrProtocolSyncLost) || !IsRetryable(err) {
+		t.Fatalf("expected retryable protocol sync error, got: %v", err)
+	}
+
+	select {
+	case <-reconnected:
+	case <-time.After(3 * time.Second):
+		t.Fatalf("client did not reconnect")
+	}
+
+	if _, err := client.Exec(context.Background(), "whoami"); err != nil {
+		t.Fatalf("Exec after reconnect failed: %v", err)
+	}
+}
+
+func TestClientWithoutReconnectFailsPermanently(t *testing.T) {
+	srv := newMockTCPServer(t, func(cmd string) []string {
+		return []string{"error id=0 msg=ok"}
+	})
+	host, port := srv.hostPort(t)
+
+	disconnected := make(chan struct{}, 1)
+	client, err := NewClient(Config{
+		Host: host,
+		Port: port,
+		OnDisconnect: func(error) {
+			disconnected <- struct{}{}
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	_ = srv.conn(0).Close()
+	select {
+	case <-disconnected:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("OnDisconnect was not called")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	_, err = client.Exec(ctx, "whoami")
+	if err == nil {
+		t.Fatalf("expected error on closed connection")
+	}
+	if IsRetryable(err) {
+		t.Fatalf("error must not be retryable without reconnect policy: %v", err)
+	}
+}
+
+func TestReconnectPolicyBackoff(t *testing.T) {
+	p := ReconnectPolicy{
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		Multiplier:     2,
+	}
+	cases := map[int]time.Duration{
+		1: 100 * time.Millisecond,
+		2: 200 * time.Millisecond,
+		4: 800 * time.Millisecond,
+		5: time.Second,
+		9: time.Second,
+	}
+	for attempt, want := range cases {
+		if got := p.backoff(attempt); got != want {
+			t.Fatalf("backoff(%d)=%v want %v", attempt, got, want)
+		}
+	}
+
+	p.Jitter = 0.5
+	for i := 0; i < 50; i++ {
+		d := p.backoff(1)
+		if d < 50*time.Millisecond || d > 150*time.Millisecond {
+			t.Fatalf("jittered backoff out of range: %v", d)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package ts3
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kinds of recorded entries.
+const (
+	RecordNotify = "notify"
+	RecordExec   = "exec"
+)
+
+// RecordEntry is one line of a recording.
+type RecordEntry struct {
+	Time time.Time `json:"time"`
+	// Kind is RecordNotify or RecordExec.
+	Kind string `json:"kind"`
+	// Line is the notification line, e.g. "notifytextmessage targetmode=1 ...".
+	Line string `json:"line,omitempty"`
+	// Command is the sent command. Passwords, tokens and API keys are
+	// replaced by "xxxxx" here and in Line and Response.
+	Command string `json:"command,omitempty"`
+	// Response holds the reply rows joined by "|".
+	Response string `json:"response,omitempty"`
+	// Status is the terminating "error id=... msg=..." line.
+	Status string `json:"status,omitempty"`
+}
+
+// Recorder writes notifications and command exchanges of a client as JSON
+// lines, one RecordEntry per line. Set it as Config.Recorder or
+// WebQueryConfig.Recorder; a recording can be played back with NewReplayConn.
+//
+// Passwords, tokens and API keys are redacted in commands, replies and
+// notifications, so recordings can be shared.
+//
+// Command replies are kept in memory until their status line arrives, so
+// recording large list commands costs memory.
+type Recorder struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	err error
+}
+
+// NewRecorder returns a recorder that writes to w. Writes are serialized, so
+// one recorder can be shared by several clients, e.g. the members of a Pool.
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{enc: json.NewEncoder(w)}
+}
+
+// Err returns the first write error. Entries after a failed write are
+// discarded.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+func (r *Recorder) write(entry RecordEntry) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return
+	}
+	r.err = r.enc.Encode(entry)
+}
+
+func (r *Recorder) notify(at time.Time, line string) {
+	r.write(RecordEntry{Time: at, Kind: RecordNotify, Line: redactParams(line)})
+}
+
+func (r *Recorder) exec(cmd string, rows []string, status string) {
+	r.write(RecordEntry{
+		Time:     time.Now(),
+		Kind:     RecordExec,
+		Command:  redactParams(cmd),
+		Response: redactParams(strings.Join(rows, "|")),
+		Status:   status,
+	})
+}
+
+// execErr records a WebQuery exchange. Transport errors have no status
+// line and are not recorded.
+func (r *Recorder) execErr(cmd, resp string, err error) {
+	if r == nil {
+		return
+	}
+	status := "error id=0 msg=ok"
+	if err != nil {
+		var qerr *Error
+		if !errors.As(err, &qerr) {
+			return
+		}
+		status = statusLine(qerr)
+	}
+	var rows []string
+	if resp != "" {
+		rows = []string{resp}
+	}
+	r.exec(cmd, rows, status)
+}
+
+func statusLine(e *Error) string {
+	line := fmt.Sprintf("error id=%d msg=%s", e.ID, Escape(e.Msg))
+	if e.ExtraMsg != "" {
+		line += " extra_msg=" + Escape(e.ExtraMsg)
+	}
+	return line
+}
+
+// secretParams are the parameters whose values are not recorded: login and
+// server passwords, privilege keys and WebQuery API keys.
+var secretParams = []string{
+	"client_login_password",
+	"pw",
+	"tcpw",
+	"cpw",
+	"channel_password",
+	"virtualserver_password",
+	"token",
+	"apikey",
+}
+
+var secretParamPattern = regexp.MustCompile(`(^|[ |])(` + strings.Join(secretParams, "|") + `)=[^ |]*`)
+
+// redactParams replaces the values of secretParams in a command, reply or
+// notification. Escaped values contain no spaces or "|".
+func redactParams(s string) string {
+	return secretParamPattern.ReplaceAllString(s, "${1}${2}="+dsnRedacted)
+}
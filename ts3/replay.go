@@ -0,0 +1,292 @@
+package ts3
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+const replayBanner = "TS3\nWelcome to the TeamSpeak 3 ServerQuery interface (replay)\n"
+
+// ErrReplayMismatch is reported when a replayed client sends a command the
+// recording has no reply for. The ReplayConn is closed, so the command fails
+// with a ConnectionError wrapping it.
+var ErrReplayMismatch = errors.New("ts3: replay: no recorded reply")
+
+// ReplayOptions configures a ReplayConn.
+type ReplayOptions struct {
+	// Speed scales the recorded gaps between notifications: 1 replays in real
+	// time, 10 ten times faster. 0 sends them without delay.
+	Speed float64
+	// LooseMatch answers a command that was not recorded with the next unused
+	// reply of the same command name, e.g. "clientinfo clid=7" with the reply
+	// recorded for "clientinfo clid=5". Off by default, as the replayed data
+	// then does not belong to the command.
+	LooseMatch bool
+}
+
+// ReplayConn plays back a recording made with a Recorder. It acts as the
+// server side of a raw ServerQuery connection and is meant for
+// NewClientFromConn:
+//
+//	replay, err := ts3.NewReplayConn(file, ts3.ReplayOptions{Speed: 10})
+//	client, err := ts3.NewClientFromConn(replay, ts3.Config{})
+//	client.OnText(ctx, handler)
+//	replay.Start()
+//	<-replay.Done()
+//
+// Commands are answered with the recorded reply of the same command, taking
+// unused entries in recording order; secrets are compared in their redacted
+// form. A command that was answered before and not recorded again gets the
+// same reply. servernotifyregister, servernotifyunregister and quit are
+// acknowledged when the recording has no reply for them. Any other command
+// without a recorded reply closes the connection with ErrReplayMismatch,
+// unless ReplayOptions.LooseMatch is set.
+type ReplayConn struct {
+	speed    float64
+	loose    bool
+	notifies []string
+	offsets  []time.Duration
+	execs    []RecordEntry
+	used     []bool
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	out      bytes.Buffer
+	in       []byte
+	closed   bool
+	err      error
+	finished bool
+
+	start     chan struct{}
+	startOnce sync.Once
+	done      chan struct{}
+	doneOnce  sync.Once
+	quit      chan struct{}
+}
+
+// NewReplayConn reads a recording from r. Notifications are held back until
+// Start is called, so handlers can be registered first.
+func NewReplayConn(r io.Reader, opts ReplayOptions) (*ReplayConn, error) {
+	if opts.Speed < 0 {
+		return nil, errors.New("ts3: replay speed must not be negative")
+	}
+	c := &ReplayConn{
+		speed: opts.Speed,
+		loose: opts.LooseMatch,
+		start: make(chan struct{}),
+		done:  make(chan struct{}),
+		quit:  make(chan struct{}),
+	}
+	c.cond = sync.NewCond(&c.mu)
+
+	var first time.Time
+	dec := json.NewDecoder(r)
+	for n := 1; ; n++ {
+		var entry RecordEntry
+		if err := dec.Decode(&entry); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("ts3: replay entry %d: %w", n, err)
+		}
+		switch entry.Kind {
+		case RecordNotify:
+			if len(c.notifies) == 0 {
+				first = entry.Time
+			}
+			c.notifies = append(c.notifies, entry.Line)
+			c.offsets = append(c.offsets, entry.Time.Sub(first))
+		case RecordExec:
+			c.execs = append(c.execs, entry)
+		default:
+			return nil, fmt.Errorf("ts3: replay entry %d: unknown kind %q", n, entry.Kind)
+		}
+	}
+	c.used = make([]bool, len(c.execs))
+	c.out.WriteString(replayBanner)
+
+	go c.run()
+	return c, nil
+}
+
+// Start begins sending the recorded notifications. The first one is sent
+// right away, the others keep their recorded gaps scaled by Speed.
+func (c *ReplayConn) Start() {
+	c.startOnce.Do(func() { close(c.start) })
+}
+
+// Done is closed when every notification was read by the client, or when the
+// connection is closed.
+func (c *ReplayConn) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *ReplayConn) run() {
+	select {
+	case <-c.start:
+	case <-c.quit:
+		return
+	}
+
+	begin := time.Now()
+	for i, line := range c.notifies {
+		if c.speed > 0 {
+			wait := time.Duration(float64(c.offsets[i])/c.speed) - time.Since(begin)
+			if wait > 0 {
+				timer := time.NewTimer(wait)
+				select {
+				case <-timer.C:
+				case <-c.quit:
+					timer.Stop()
+					return
+				}
+			}
+		}
+		c.mu.Lock()
+		c.writeLocked(line)
+		c.mu.Unlock()
+	}
+
+	c.mu.Lock()
+	c.finished = true
+	if c.out.Len() == 0 {
+		c.closeDone()
+	}
+	c.mu.Unlock()
+}
+
+// Read returns the banner, replies and notifications in the order they were
+// queued.
+func (c *ReplayConn) Read(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for c.out.Len() == 0 && !c.closed {
+		c.cond.Wait()
+	}
+	if c.closed {
+		if c.err != nil {
+			return 0, c.err
+		}
+		return 0, io.EOF
+	}
+	n, _ := c.out.Read(p)
+	if c.finished && c.out.Len() == 0 {
+		c.closeDone()
+	}
+	return n, nil
+}
+
+// Write takes commands and queues their recorded replies.
+func (c *ReplayConn) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		if c.err != nil {
+			return 0, c.err
+		}
+		return 0, io.ErrClosedPipe
+	}
+	c.in = append(c.in, p...)
+	for {
+		i := bytes.IndexByte(c.in, '\n')
+		if i < 0 {
+			break
+		}
+		cmd := strings.TrimSpace(string(c.in[:i]))
+		c.in = c.in[i+1:]
+		if cmd != "" && !c.replyLocked(cmd) {
+			break
+		}
+	}
+	return len(p), nil
+}
+
+// Close stops the replay. Pending output is discarded.
+func (c *ReplayConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closeLocked(nil)
+	return nil
+}
+
+// Err returns the ErrReplayMismatch that closed the connection, if any.
+func (c *ReplayConn) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+func (c *ReplayConn) closeLocked(err error) {
+	if c.closed {
+		return
+	}
+	c.closed = true
+	c.err = err
+	close(c.quit)
+	c.cond.Broadcast()
+	c.closeDone()
+}
+
+// replyLocked queues the reply of cmd. It closes the connection and returns
+// false when there is none.
+func (c *ReplayConn) replyLocked(cmd string) bool {
+	entry, ok := c.match(cmd)
+	if !ok {
+		switch commandName(cmd) {
+		case "quit", "servernotifyregister", "servernotifyunregister":
+			c.writeLocked("error id=0 msg=ok")
+			return true
+		}
+		c.closeLocked(fmt.Errorf("%w for %q", ErrReplayMismatch, redactParams(cmd)))
+		return false
+	}
+	if entry.Response != "" {
+		c.writeLocked(entry.Response)
+	}
+	c.writeLocked(entry.Status)
+	return true
+}
+
+// match finds the recorded reply of cmd.
+func (c *ReplayConn) match(cmd string) (RecordEntry, bool) {
+	redacted := redactParams(cmd)
+	for i, entry := range c.execs {
+		if !c.used[i] && entry.Command == redacted {
+			c.used[i] = true
+			return entry, true
+		}
+	}
+	for i := len(c.execs) - 1; i >= 0; i-- {
+		if c.execs[i].Command == redacted {
+			return c.execs[i], true
+		}
+	}
+	if c.loose {
+		name := commandName(cmd)
+		for i, entry := range c.execs {
+			if !c.used[i] && commandName(entry.Command) == name {
+				c.used[i] = true
+				return entry, true
+			}
+		}
+	}
+	return RecordEntry{}, false
+}
+
+func (c *ReplayConn) writeLocked(line string) {
+	if c.closed {
+		return
+	}
+	c.out.WriteString(line)
+	c.out.WriteByte('\n')
+	c.cond.Broadcast()
+}
+
+func (c *ReplayConn) closeDone() {
+	c.doneOnce.Do(func() { close(c.done) })
+}
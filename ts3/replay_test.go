@@ -0,0 +1,241 @@
+package ts3
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jkesh/ts3-go/v2/ts3/models"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	conn := newMockServerConn(t, func(cmd string) []string {
+		switch {
+		case strings.HasPrefix(cmd, "login "):
+			return []string{"error id=0 msg=ok"}
+		case cmd == "whoami":
+			return []string{
+				`notifytextmessage targetmode=1 msg=hello\sworld invokerid=4 invokername=Alice`,
+				"virtualserver_id=1 client_id=5 client_channel_id=2 client_nickname=Bot",
+				"error id=0 msg=ok",
+			}
+		case cmd == "channellist":
+			return []string{"cid=1 channel_name=Lobby|cid=2 channel_name=AFK", "error id=0 msg=ok"}
+		default:
+			return []string{"error id=256 msg=command\\snot\\sfound"}
+		}
+	})
+
+	var recording bytes.Buffer
+	rec := NewRecorder(&recording)
+	client, err := NewClientFromConn(conn, Config{Recorder: rec})
+	if err != nil {
+		t.Fatalf("NewClientFromConn failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if err := client.Login(ctx, "serveradmin", "secret"); err != nil {
+		t.Fatalf("Login failed: %v", err)
+	}
+	if _, err := client.WhoAmI(ctx); err != nil {
+		t.Fatalf("WhoAmI failed: %v", err)
+	}
+	if _, err := client.ChannelList(ctx); err != nil {
+		t.Fatalf("ChannelList failed: %v", err)
+	}
+	if _, err := client.Exec(ctx, "unknowncommand"); err == nil {
+		t.Fatalf("expected unknowncommand to fail")
+	}
+	client.Close()
+	if err := rec.Err(); err != nil {
+		t.Fatalf("recorder failed: %v", err)
+	}
+
+	if strings.Contains(recording.String(), "secret") {
+		t.Fatalf("recording contains the password:\n%s", recording.String())
+	}
+	var kinds []string
+	dec := json.NewDecoder(bytes.NewReader(recording.Bytes()))
+	for {
+		var entry RecordEntry
+		if err := dec.Decode(&entry); err != nil {
+			break
+		}
+		if entry.Time.IsZero() {
+			t.Fatalf("entry without time: %+v", entry)
+		}
+		kinds = append(kinds, entry.Kind)
+		if entry.Command == "channellist" && entry.Response != "cid=1 channel_name=Lobby|cid=2 channel_name=AFK" {
+			t.Fatalf("unexpected channellist entry: %+v", entry)
+		}
+	}
+	if len(kinds) != 5 {
+		t.Fatalf("expected 4 exec and 1 notify entries, got %v", kinds)
+	}
+
+	replay, err := NewReplayConn(bytes.NewReader(recording.Bytes()), ReplayOptions{})
+	if err != nil {
+		t.Fatalf("NewReplayConn failed: %v", err)
+	}
+	client, err = NewClientFromConn(replay, Config{})
+	if err != nil {
+		t.Fatalf("NewClientFromConn on replay failed: %v", err)
+	}
+	defer client.Close()
+
+	text := make(chan models.TextMessage, 1)
+	if _, err := client.OnText(ctx, func(ev models.TextMessage) { text <- ev }); err != nil {
+		t.Fatalf("OnText failed: %v", err)
+	}
+	if err := client.Login(ctx, "serveradmin", "other"); err != nil {
+		t.Fatalf("replayed Login failed: %v", err)
+	}
+	channels, err := client.ChannelList(ctx)
+	if err != nil || len(channels) != 2 || channels[1].Name != "AFK" {
+		t.Fatalf("unexpected replayed channels: %+v, %v", channels, err)
+	}
+	// Commands answered before get the same reply again.
+	if _, err := client.ChannelList(ctx); err != nil {
+		t.Fatalf("repeated ChannelList failed: %v", err)
+	}
+	_, err = client.Exec(ctx, "unknowncommand")
+	var qerr *Error
+	if !errors.As(err, &qerr) || !qerr.Is(ErrCommandNotFound) {
+		t.Fatalf("expected recorded command not found error, got %v", err)
+	}
+
+	replay.Start()
+	select {
+	case ev := <-text:
+		if ev.Message != "hello world" || ev.InvokerName != "Alice" {
+			t.Fatalf("unexpected replayed text: %+v", ev)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("replayed notification not delivered")
+	}
+	select {
+	case <-replay.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatalf("replay not done")
+	}
+}
+
+func TestReplayFailsOnUnrecordedCommand(t *testing.T) {
+	recording := `{"kind":"exec","command":"clientinfo clid=5","response":"cid=2 client_nickname=Alice","status":"error id=0 msg=ok"}` + "\n"
+
+	replay, err := NewReplayConn(strings.NewReader(recording), ReplayOptions{})
+	if err != nil {
+		t.Fatalf("NewReplayConn failed: %v", err)
+	}
+	client, err := NewClientFromConn(replay, Config{})
+	if err != nil {
+		t.Fatalf("NewClientFromConn failed: %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if _, err := client.Exec(ctx, "clientinfo clid=7"); !errors.Is(err, ErrReplayMismatch) {
+		t.Fatalf("expected ErrReplayMismatch, got %v", err)
+	}
+	if err := replay.Err(); err == nil || !strings.Contains(err.Error(), "clientinfo clid=7") {
+		t.Fatalf("unexpected replay error: %v", err)
+	}
+
+	replay, err = NewReplayConn(strings.NewReader(recording), ReplayOptions{LooseMatch: true})
+	if err != nil {
+		t.Fatalf("NewReplayConn failed: %v", err)
+	}
+	client, err = NewClientFromConn(replay, Config{})
+	if err != nil {
+		t.Fatalf("NewClientFromConn failed: %v", err)
+	}
+	defer client.Close()
+	resp, err := client.Exec(ctx, "clientinfo clid=7")
+	if err != nil || resp != "cid=2 client_nickname=Alice" {
+		t.Fatalf("unexpected loosely matched reply: %q, %v", resp, err)
+	}
+}
+
+func TestRecorderRedactsSecrets(t *testing.T) {
+	for _, key := range secretParams {
+		t.Run(key, func(t *testing.T) {
+			var recording bytes.Buffer
+			rec := NewRecorder(&recording)
+			rec.exec("somecommand "+key+"=s3cr\\sit sid=1", []string{key + "=s3cr\\sit cid=2|" + key + "=other"}, "error id=0 msg=ok")
+			rec.notify(time.Now(), "notifysomething cid=2 "+key+"=s3cr\\sit")
+
+			if strings.Contains(recording.String(), "s3cr") || strings.Contains(recording.String(), "other") {
+				t.Fatalf("recording contains the secret:\n%s", recording.String())
+			}
+			dec := json.NewDecoder(&recording)
+			var exec, notify RecordEntry
+			if err := dec.Decode(&exec); err != nil {
+				t.Fatalf("decode exec entry: %v", err)
+			}
+			if err := dec.Decode(&notify); err != nil {
+				t.Fatalf("decode notify entry: %v", err)
+			}
+			if want := "somecommand " + key + "=xxxxx sid=1"; exec.Command != want {
+				t.Fatalf("command = %q, want %q", exec.Command, want)
+			}
+			if want := key + "=xxxxx cid=2|" + key + "=xxxxx"; exec.Response != want {
+				t.Fatalf("response = %q, want %q", exec.Response, want)
+			}
+			if want := "notifysomething cid=2 " + key + "=xxxxx"; notify.Line != want {
+				t.Fatalf("line = %q, want %q", notify.Line, want)
+			}
+		})
+	}
+
+	// Parameters that only end in a secret name are kept.
+	if got := redactParams("clientupdate client_npw=1 client_description=pw=1"); got != "clientupdate client_npw=1 client_description=pw=1" {
+		t.Fatalf("unexpected redaction: %q", got)
+	}
+}
+
+func TestReplayKeepsScaledGaps(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	var recording bytes.Buffer
+	rec := NewRecorder(&recording)
+	rec.notify(start, "notifyclientmoved ctid=2 reasonid=0 clid=5")
+	rec.notify(start.Add(5*time.Second), "notifyclientmoved ctid=3 reasonid=0 clid=5")
+
+	replay, err := NewReplayConn(&recording, ReplayOptions{Speed: 50})
+	if err != nil {
+		t.Fatalf("NewReplayConn failed: %v", err)
+	}
+	client, err := NewClientFromConn(replay, Config{})
+	if err != nil {
+		t.Fatalf("NewClientFromConn failed: %v", err)
+	}
+	defer client.Close()
+
+	events := client.Events(context.Background(), EventFilter{Names: []string{EventClientMoved}})
+	began := time.Now()
+	replay.Start()
+	for _, want := range []string{"ctid=2", "ctid=3"} {
+		select {
+		case ev := <-events:
+			if !strings.HasPrefix(ev.Data, want) {
+				t.Fatalf("unexpected event %q, want %q", ev.Data, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("event %q not delivered", want)
+		}
+	}
+	// 5s at 50x speed are 100ms.
+	if elapsed := time.Since(began); elapsed < 80*time.Millisecond || elapsed > time.Second {
+		t.Fatalf("unexpected replay duration %v", elapsed)
+	}
+
+	if _, err := NewReplayConn(strings.NewReader(`{"kind":"bogus"}`), ReplayOptions{}); err == nil {
+		t.Fatalf("expected unknown kind to fail")
+	}
+}
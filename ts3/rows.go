@@ -0,0 +1,100 @@
+package ts3
+
+import (
+	"context"
+	"iter"
+	"strings"
+)
+
+// Row is one row of a command response, e.g. one client of clientdblist.
+type Row struct {
+	raw string
+}
+
+// String returns the row in ServerQuery syntax with escaped values.
+func (r Row) String() string {
+	return r.raw
+}
+
+// Get returns the unescaped value of key.
+func (r Row) Get(key string) (string, bool) {
+	for _, field := range strings.Fields(r.raw) {
+		k, v, _ := strings.Cut(field, "=")
+		if k == key {
+			return Unescape(v), true
+		}
+	}
+	return "", false
+}
+
+// Map returns all keys of the row with unescaped values.
+func (r Row) Map() map[string]string {
+	resp := tokenizeResponse(r.raw)
+	if resp.len() == 0 {
+		return map[string]string{}
+	}
+	return resp.rowMap(0)
+}
+
+// Decode decodes the row into v like Decoder.Decode.
+func (r Row) Decode(v interface{}) error {
+	return NewDecoder().Decode(r.raw, v)
+}
+
+// ExecRows sends a raw command and yields the response rows one at a time as
+// they are read from the connection, without buffering the whole response:
+//
+//	for row, err := range client.ExecRows(ctx, "clientdblist start=0 duration=100000") {
+//		if err != nil {
+//			return err
+//		}
+//		log.Println(row.Get("client_nickname"))
+//	}
+//
+// A failing command yields a single error after the rows received so far.
+// Stopping the loop early discards the remaining rows. The connection is
+// reserved while the loop runs, so the loop body must not run other commands
+// on the same client. WebQuery clients receive the response as a whole and
+// split it afterwards.
+func (c *Client) ExecRows(ctx context.Context, cmd string) iter.Seq2[Row, error] {
+	return func(yield func(Row, error) bool) {
+		stopped := false
+		err := c.exec(ctx, cmd, nil, func(row string) bool {
+			if !yield(Row{raw: row}, nil) {
+				stopped = true
+				return false
+			}
+			return true
+		})
+		if err != nil && !stopped {
+			yield(Row{}, err)
+		}
+	}
+}
+
+// DecodeSeq decodes every row of rows into a T, which must be a struct or
+// map[string]string:
+//
+//	for dbc, err := range ts3.DecodeSeq[models.DBClient](client.ExecRows(ctx, cmd)) {
+//		...
+//	}
+//
+// The sequence stops after the first error.
+func DecodeSeq[T any](rows iter.Seq2[Row, error]) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		dec := NewDecoder()
+		for row, err := range rows {
+			var v T
+			if err == nil {
+				err = dec.Decode(row.raw, &v)
+			}
+			if err != nil {
+				yield(v, err)
+				return
+			}
+			if !yield(v, nil) {
+				return
+			}
+		}
+	}
+}
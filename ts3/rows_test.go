@@ -0,0 +1,118 @@
+package ts3
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExecRowsStreamsAndStaysInSync(t *testing.T) {
+	conn := newMockServerConn(t, func(cmd string) []string {
+		switch cmd {
+		case "clientdblist":
+			return []string{
+				"cldbid=1 client_nickname=A|cldbid=2 client_nickname=B\\sC|cldbid=3 client_nickname=D",
+				"error id=0 msg=ok",
+			}
+		case "whoami":
+			return []string{"client_id=5", "error id=0 msg=ok"}
+		}
+		return []string{"error id=256 msg=command\\snot\\sfound"}
+	})
+
+	client, err := NewClientFromConn(conn, Config{})
+	if err != nil {
+		t.Fatalf("NewClientFromConn failed: %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	type dbClient struct {
+		ID       int    `ts3:"cldbid"`
+		Nickname string `ts3:"client_nickname"`
+	}
+	var got []dbClient
+	for c, err := range DecodeSeq[dbClient](client.ExecRows(ctx, "clientdblist")) {
+		if err != nil {
+			t.Fatalf("row error: %v", err)
+		}
+		got = append(got, c)
+	}
+	if len(got) != 3 || got[1].Nickname != "B C" || got[2].ID != 3 {
+		t.Fatalf("unexpected rows: %+v", got)
+	}
+
+	// Stop after the first row; the remaining rows must be drained.
+	for row, err := range client.ExecRows(ctx, "clientdblist") {
+		if err != nil {
+			t.Fatalf("row error: %v", err)
+		}
+		if name, _ := row.Get("client_nickname"); name != "A" {
+			t.Fatalf("unexpected first row: %s", row)
+		}
+		break
+	}
+
+	resp, err := client.Exec(ctx, "whoami")
+	if err != nil || resp != "client_id=5" {
+		t.Fatalf("Exec after early break: resp=%q err=%v", resp, err)
+	}
+
+	var lastErr error
+	for _, err := range client.ExecRows(ctx, "unknown") {
+		lastErr = err
+	}
+	var qerr *Error
+	if !errors.As(lastErr, &qerr) || qerr.ID != 256 {
+		t.Fatalf("expected query error, got %v", lastErr)
+	}
+}
+
+func TestReadLoopJoinsMultiRowNotifications(t *testing.T) {
+	conn := newMockServerConn(t, func(cmd string) []string {
+		if cmd == "trigger" {
+			return []string{"notifyclientmoved ctid=2 reasonid=0 clid=5|clid=6", "error id=0 msg=ok"}
+		}
+		return []string{"error id=0 msg=ok"}
+	})
+
+	client, err := NewClientFromConn(conn, Config{})
+	if err != nil {
+		t.Fatalf("NewClientFromConn failed: %v", err)
+	}
+	defer client.Close()
+
+	got := make(chan string, 1)
+	client.Register("notifyclientmoved", func(data string) { got <- data })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if resp, err := client.Exec(ctx, "trigger"); err != nil || resp != "" {
+		t.Fatalf("Exec: resp=%q err=%v", resp, err)
+	}
+
+	select {
+	case data := <-got:
+		if data != "ctid=2 reasonid=0 clid=5|clid=6" {
+			t.Fatalf("unexpected notification data: %q", data)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("notification not dispatched")
+	}
+}
+
+func TestScanRows(t *testing.T) {
+	scanner := newLineScanner(strings.NewReader("a=1|a=2\r\nb=3\nc"), 64)
+	var tokens []string
+	for scanner.Scan() {
+		tokens = append(tokens, scanner.Text())
+	}
+	want := []string{"a=1|", "a=2", "b=3", "c"}
+	if strings.Join(tokens, ",") != strings.Join(want, ",") {
+		t.Fatalf("tokens = %q, want %q", tokens, want)
+	}
+}
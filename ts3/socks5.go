@@ -0,0 +1,214 @@
+package ts3
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+const (
+	socks5Version      = 0x05
+	socks5AuthNone     = 0x00
+	socks5AuthPassword = 0x02
+	socks5CmdConnect   = 0x01
+	socks5AddrIPv4     = 0x01
+	socks5AddrDomain   = 0x03
+	socks5AddrIPv6     = 0x04
+)
+
+var socks5Replies = map[byte]string{
+	0x01: "general failure",
+	0x02: "connection not allowed by ruleset",
+	0x03: "network unreachable",
+	0x04: "host unreachable",
+	0x05: "connection refused",
+	0x06: "TTL expired",
+	0x07: "command not supported",
+	0x08: "address type not supported",
+}
+
+// SOCKS5 dials through a SOCKS5 proxy (RFC 1928) with optional
+// username/password authentication (RFC 1929).
+//
+// Use its DialContext as Config.Dialer:
+//
+//	proxy := &ts3.SOCKS5{Addr: "jump.example.com:1080"}
+//	client, err := ts3.NewClient(ts3.Config{Host: "10.0.0.5", Dialer: proxy.DialContext})
+//
+// The target host name is resolved by the proxy.
+type SOCKS5 struct {
+	// Addr is the proxy address (host:port).
+	Addr     string
+	Username string
+	Password string
+	// Forward dials the proxy itself, e.g. another SOCKS5 hop. Nil uses a
+	// net.Dialer.
+	Forward func(ctx context.Context, network, addr string) (net.Conn, error)
+}
+
+// DialContext connects to addr through the proxy. Only "tcp" networks are
+// supported.
+func (s *SOCKS5) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+	default:
+		return nil, fmt.Errorf("ts3: socks5: unsupported network %q", network)
+	}
+
+	var (
+		conn net.Conn
+		err  error
+	)
+	if s.Forward != nil {
+		conn, err = s.Forward(ctx, "tcp", s.Addr)
+	} else {
+		var d net.Dialer
+		conn, err = d.DialContext(ctx, "tcp", s.Addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ts3: socks5: dial proxy %s: %w", s.Addr, err)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.SetDeadline(time.Unix(1, 0))
+	})
+
+	err = s.handshake(conn, addr)
+	if !stop() {
+		err = ctx.Err()
+	}
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	_ = conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+func (s *SOCKS5) handshake(conn net.Conn, addr string) error {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("ts3: socks5: %w", err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port <= 0 || port > 65535 {
+		return fmt.Errorf("ts3: socks5: invalid port %q", portStr)
+	}
+
+	if err := s.authenticate(conn); err != nil {
+		return err
+	}
+
+	req := []byte{socks5Version, socks5CmdConnect, 0x00}
+	if ip := net.ParseIP(host); ip != nil {
+		if ip4 := ip.To4(); ip4 != nil {
+			req = append(req, socks5AddrIPv4)
+			req = append(req, ip4...)
+		} else {
+			req = append(req, socks5AddrIPv6)
+			req = append(req, ip.To16()...)
+		}
+	} else {
+		if len(host) > 255 {
+			return errors.New("ts3: socks5: host name too long")
+		}
+		req = append(req, socks5AddrDomain, byte(len(host)))
+		req = append(req, host...)
+	}
+	req = binary.BigEndian.AppendUint16(req, uint16(port))
+	if _, err := conn.Write(req); err != nil {
+		return fmt.Errorf("ts3: socks5: write connect request: %w", err)
+	}
+
+	var head [4]byte
+	if _, err := io.ReadFull(conn, head[:]); err != nil {
+		return fmt.Errorf("ts3: socks5: read connect reply: %w", err)
+	}
+	if head[0] != socks5Version {
+		return fmt.Errorf("ts3: socks5: unexpected protocol version %d", head[0])
+	}
+	if head[1] != 0x00 {
+		msg, ok := socks5Replies[head[1]]
+		if !ok {
+			msg = "unknown error " + strconv.Itoa(int(head[1]))
+		}
+		return fmt.Errorf("ts3: socks5: connect to %s failed: %s", addr, msg)
+	}
+
+	// Skip the bound address.
+	var skip int
+	switch head[3] {
+	case socks5AddrIPv4:
+		skip = net.IPv4len
+	case socks5AddrIPv6:
+		skip = net.IPv6len
+	case socks5AddrDomain:
+		var l [1]byte
+		if _, err := io.ReadFull(conn, l[:]); err != nil {
+			return fmt.Errorf("ts3: socks5: read connect reply: %w", err)
+		}
+		skip = int(l[0])
+	default:
+		return fmt.Errorf("ts3: socks5: unknown address type %d", head[3])
+	}
+	if _, err := io.CopyN(io.Discard, conn, int64(skip+2)); err != nil {
+		return fmt.Errorf("ts3: socks5: read connect reply: %w", err)
+	}
+	return nil
+}
+
+func (s *SOCKS5) authenticate(conn net.Conn) error {
+	methods := []byte{socks5AuthNone}
+	if s.Username != "" || s.Password != "" {
+		if len(s.Username) > 255 || len(s.Password) > 255 {
+			return errors.New("ts3: socks5: username or password too long")
+		}
+		methods = []byte{socks5AuthPassword}
+	}
+
+	greeting := append([]byte{socks5Version, byte(len(methods))}, methods...)
+	if _, err := conn.Write(greeting); err != nil {
+		return fmt.Errorf("ts3: socks5: write greeting: %w", err)
+	}
+
+	var reply [2]byte
+	if _, err := io.ReadFull(conn, reply[:]); err != nil {
+		return fmt.Errorf("ts3: socks5: read greeting: %w", err)
+	}
+	if reply[0] != socks5Version {
+		return fmt.Errorf("ts3: socks5: unexpected protocol version %d", reply[0])
+	}
+
+	switch reply[1] {
+	case socks5AuthNone:
+		return nil
+	case socks5AuthPassword:
+		if s.Username == "" && s.Password == "" {
+			break
+		}
+		req := []byte{0x01, byte(len(s.Username))}
+		req = append(req, s.Username...)
+		req = append(req, byte(len(s.Password)))
+		req = append(req, s.Password...)
+		if _, err := conn.Write(req); err != nil {
+			return fmt.Errorf("ts3: socks5: write credentials: %w", err)
+		}
+		if _, err := io.ReadFull(conn, reply[:]); err != nil {
+			return fmt.Errorf("ts3: socks5: read auth reply: %w", err)
+		}
+		if reply[1] != 0x00 {
+			return errors.New("ts3: socks5: authentication failed")
+		}
+		return nil
+	}
+	return errors.New("ts3: socks5: no acceptable authentication method")
+}
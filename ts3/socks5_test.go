@@ -0,0 +1,167 @@
+package ts3
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// newMockSOCKS5 starts a SOCKS5 proxy that requires user/pass and forwards
+// CONNECT requests.
+func newMockSOCKS5(t *testing.T, user, pass string) (string, *atomic.Int32) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	var connects atomic.Int32
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				target, err := serveSOCKS5Handshake(conn, user, pass)
+				if err != nil {
+					return
+				}
+				defer target.Close()
+				connects.Add(1)
+				go func() { _, _ = io.Copy(target, conn) }()
+				_, _ = io.Copy(conn, target)
+			}()
+		}
+	}()
+	return ln.Addr().String(), &connects
+}
+
+func serveSOCKS5Handshake(conn net.Conn, user, pass string) (net.Conn, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(conn, head[:]); err != nil {
+		return nil, err
+	}
+	if _, err := io.CopyN(io.Discard, conn, int64(head[1])); err != nil {
+		return nil, err
+	}
+	_, _ = conn.Write([]byte{0x05, 0x02})
+
+	var buf [256]byte
+	if _, err := io.ReadFull(conn, buf[:2]); err != nil {
+		return nil, err
+	}
+	gotUser := make([]byte, buf[1])
+	_, _ = io.ReadFull(conn, gotUser)
+	_, _ = io.ReadFull(conn, buf[:1])
+	gotPass := make([]byte, buf[0])
+	_, _ = io.ReadFull(conn, gotPass)
+	if string(gotUser) != user || string(gotPass) != pass {
+		_, _ = conn.Write([]byte{0x01, 0x01})
+		return nil, errors.New("bad credentials")
+	}
+	_, _ = conn.Write([]byte{0x01, 0x00})
+
+	if _, err := io.ReadFull(conn, buf[:5]); err != nil {
+		return nil, err
+	}
+	if buf[3] != 0x03 {
+		return nil, errors.New("expected domain address")
+	}
+	host := make([]byte, buf[4])
+	_, _ = io.ReadFull(conn, host)
+	_, _ = io.ReadFull(conn, buf[:2])
+	port := binary.BigEndian.Uint16(buf[:2])
+
+	target, err := net.Dial("tcp", net.JoinHostPort(string(host), strconv.Itoa(int(port))))
+	if err != nil {
+		_, _ = conn.Write([]byte{0x05, 0x05, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+		return nil, err
+	}
+	_, _ = conn.Write([]byte{0x05, 0x00, 0x00, 0x01, 127, 0, 0, 1, 0, 0})
+	return target, nil
+}
+
+func TestSOCKS5DialerForRawAndSSH(t *testing.T) {
+	proxyAddr, connects := newMockSOCKS5(t, "jump", "s3cret")
+	proxy := &SOCKS5{Addr: proxyAddr, Username: "jump", Password: "s3cret"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	srv := newMockTCPServer(t, okQueryHandler)
+	_, port := srv.hostPort(t)
+	client, err := NewClientContext(ctx, Config{Host: "localhost", Port: port, Dialer: proxy.DialContext})
+	if err != nil {
+		t.Fatalf("NewClientContext via proxy failed: %v", err)
+	}
+	if _, err := client.WhoAmI(ctx); err != nil {
+		t.Fatalf("WhoAmI via proxy failed: %v", err)
+	}
+	_ = client.Close()
+
+	sshSrv := newMockSSHServer(t, okQueryHandler)
+	cfg := sshSrv.config(t)
+	cfg.Host = "localhost"
+	cfg.Dialer = proxy.DialContext
+	sshClient, err := NewSSHClientContext(ctx, cfg, SSHConfig{
+		User:               "serveradmin",
+		Password:           "secret",
+		HostKeyFingerprint: ssh.FingerprintSHA256(sshSrv.hostKey.PublicKey()),
+	})
+	if err != nil {
+		t.Fatalf("NewSSHClientContext via proxy failed: %v", err)
+	}
+	_ = sshClient.Close()
+
+	if got := connects.Load(); got != 2 {
+		t.Fatalf("expected two proxied connections, got %d", got)
+	}
+
+	bad := &SOCKS5{Addr: proxyAddr, Username: "jump", Password: "wrong"}
+	if _, err := NewClientContext(ctx, Config{Host: "localhost", Port: port, Dialer: bad.DialContext}); err == nil {
+		t.Fatalf("expected proxy authentication failure")
+	}
+}
+
+func TestNewClientContextCancelsHandshake(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		// Accept but never send the banner.
+		conn, err := ln.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(2 * time.Second)
+		}
+	}()
+
+	_, portStr, _ := net.SplitHostPort(ln.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = NewClientContext(ctx, Config{Host: "127.0.0.1", Port: port})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline error, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("NewClientContext returned too late: %v", elapsed)
+	}
+}
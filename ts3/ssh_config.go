@@ -0,0 +1,204 @@
+package ts3
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+var errSSHHostKeyNotConfigured = errors.New(
+	"ts3: ssh host key verification is not configured " +
+		"(set KnownHostsFiles, HostKeyFingerprint, HostKeyCallback or InsecureIgnoreHostKey)",
+)
+
+// SSHConfig configures authentication and host key verification for SSH
+// ServerQuery connections.
+//
+// At least one host key option must be set. When several are set, the host
+// key has to pass all of them.
+type SSHConfig struct {
+	User     string
+	Password string
+
+	// PrivateKey is a PEM encoded private key used for public-key auth.
+	PrivateKey []byte
+	// PrivateKeyFile is read when PrivateKey is empty.
+	PrivateKeyFile string
+	// Passphrase decrypts an encrypted private key.
+	Passphrase string
+	// Signers are additional keys for public-key auth.
+	Signers []ssh.Signer
+	// UseAgent enables auth through a running ssh-agent.
+	UseAgent bool
+	// AgentSocket is the agent socket path. Default: $SSH_AUTH_SOCK.
+	AgentSocket string
+	// KeyboardInteractive answers keyboard-interactive challenges. When nil
+	// and Password is set, the password answers every prompt.
+	KeyboardInteractive ssh.KeyboardInteractiveChallenge
+
+	// KnownHostsFiles are OpenSSH known_hosts files to check the host key with.
+	KnownHostsFiles []string
+	// HostKeyFingerprint pins the host key by its SHA256 fingerprint as
+	// printed by "ssh-keygen -lf", e.g. "SHA256:uL3V...".
+	HostKeyFingerprint string
+	// HostKeyCallback is a custom host key check.
+	HostKeyCallback ssh.HostKeyCallback
+	// InsecureIgnoreHostKey disables host key verification. Test use only.
+	InsecureIgnoreHostKey bool
+}
+
+// clientConfig builds the ssh.ClientConfig for one dial. cleanup releases
+// the ssh-agent connection and must be called after the handshake.
+func (c SSHConfig) clientConfig(host string, timeout time.Duration) (*ssh.ClientConfig, func(), error) {
+	hostKeyCallback, err := c.hostKeyCallback(host)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	auth, cleanup, err := c.authMethods()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &ssh.ClientConfig{
+		User:            c.User,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         timeout,
+	}, cleanup, nil
+}
+
+func (c SSHConfig) hostKeyCallback(host string) (ssh.HostKeyCallback, error) {
+	var checks []ssh.HostKeyCallback
+
+	if len(c.KnownHostsFiles) > 0 {
+		cb, err := knownhosts.New(c.KnownHostsFiles...)
+		if err != nil {
+			return nil, fmt.Errorf("ts3: load known_hosts failed: %w", err)
+		}
+		checks = append(checks, cb)
+	}
+	if fp := strings.TrimSpace(c.HostKeyFingerprint); fp != "" {
+		checks = append(checks, fingerprintCallback(fp))
+	}
+	if c.HostKeyCallback != nil {
+		checks = append(checks, c.HostKeyCallback)
+	}
+
+	if len(checks) == 0 {
+		if c.InsecureIgnoreHostKey {
+			return ssh.InsecureIgnoreHostKey(), nil
+		}
+		return nil, errSSHHostKeyNotConfigured
+	}
+
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		for _, check := range checks {
+			if err := check(hostname, remote, key); err != nil {
+				return &HostKeyError{
+					Host:        host,
+					Fingerprint: ssh.FingerprintSHA256(key),
+					Err:         err,
+				}
+			}
+		}
+		return nil
+	}, nil
+}
+
+func fingerprintCallback(want string) ssh.HostKeyCallback {
+	if !strings.HasPrefix(want, "SHA256:") {
+		want = "SHA256:" + want
+	}
+	return func(_ string, _ net.Addr, key ssh.PublicKey) error {
+		got := ssh.FingerprintSHA256(key)
+		if subtle.ConstantTimeCompare([]byte(got), []byte(want)) != 1 {
+			return fmt.Errorf("fingerprint mismatch: got %s, want %s", got, want)
+		}
+		return nil
+	}
+}
+
+func (c SSHConfig) authMethods() ([]ssh.AuthMethod, func(), error) {
+	cleanup := func() {}
+	signers := append([]ssh.Signer(nil), c.Signers...)
+
+	key := c.PrivateKey
+	if len(key) == 0 && c.PrivateKeyFile != "" {
+		data, err := os.ReadFile(c.PrivateKeyFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("ts3: read ssh private key failed: %w", err)
+		}
+		key = data
+	}
+	if len(key) > 0 {
+		var (
+			signer ssh.Signer
+			err    error
+		)
+		if c.Passphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase(key, []byte(c.Passphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey(key)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("ts3: parse ssh private key failed: %w", err)
+		}
+		signers = append(signers, signer)
+	}
+
+	var methods []ssh.AuthMethod
+	if len(signers) > 0 {
+		methods = append(methods, ssh.PublicKeys(signers...))
+	}
+
+	if c.UseAgent {
+		socket := c.AgentSocket
+		if socket == "" {
+			socket = os.Getenv("SSH_AUTH_SOCK")
+		}
+		if socket == "" {
+			return nil, nil, errors.New("ts3: ssh agent socket is not set")
+		}
+		conn, err := net.Dial("unix", socket)
+		if err != nil {
+			return nil, nil, fmt.Errorf("ts3: connect ssh agent failed: %w", err)
+		}
+		cleanup = func() { _ = conn.Close() }
+		methods = append(methods, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+	}
+
+	if c.Password != "" {
+		methods = append(methods, ssh.Password(c.Password))
+	}
+
+	switch {
+	case c.KeyboardInteractive != nil:
+		methods = append(methods, ssh.KeyboardInteractive(c.KeyboardInteractive))
+	case c.Password != "":
+		password := c.Password
+		methods = append(methods, ssh.KeyboardInteractive(
+			func(_, _ string, questions []string, _ []bool) ([]string, error) {
+				answers := make([]string, len(questions))
+				for i := range answers {
+					answers[i] = password
+				}
+				return answers, nil
+			},
+		))
+	}
+
+	if len(methods) == 0 {
+		cleanup()
+		return nil, nil, errors.New("ts3: no ssh authentication method configured")
+	}
+	return methods, cleanup, nil
+}
@@ -0,0 +1,183 @@
+package ts3
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+)
+
+var errSSHConnClosed = errors.New("ts3: ssh connection closed")
+
+// SSHConn is one authenticated SSH connection that carries several
+// independent query sessions, one per shell channel.
+//
+// The SSH handshake and authentication happen once; every Client returned by
+// NewClient only opens a new channel. The connection is closed when the last
+// session closes and is dialed again on demand, e.g. by a reconnecting
+// client.
+type SSHConn struct {
+	addr   string
+	cfg    Config
+	sshCfg SSHConfig
+
+	mu       sync.Mutex
+	client   *ssh.Client
+	sessions int
+	closed   bool
+}
+
+// DialSSH opens an SSH connection for multiple query sessions.
+//
+// cfg.Host is required; cfg.Port defaults to 10022. cfg is also used for the
+// clients created by NewClient.
+func DialSSH(cfg Config, sshCfg SSHConfig) (*SSHConn, error) {
+	if strings.TrimSpace(cfg.Host) == "" {
+		return nil, errors.New("ts3: host is required")
+	}
+	if cfg.Port == 0 {
+		cfg.Port = defaultQuerySSHPort
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultDialTimeout
+	}
+
+	s := &SSHConn{
+		addr:   net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		cfg:    cfg,
+		sshCfg: sshCfg,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.connectLocked(context.Background()); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// NewClient opens a new query session on the shared SSH connection.
+func (s *SSHConn) NewClient() (*Client, error) {
+	ctx := context.Background()
+	wrapper, err := s.openSession(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newClientFromConn(ctx, wrapper, s.cfg, true, func(ctx context.Context) (io.ReadWriteCloser, error) {
+		wrapper, err := s.openSession(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return wrapper, nil
+	})
+}
+
+// Sessions returns the number of open query sessions.
+func (s *SSHConn) Sessions() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sessions
+}
+
+// Close closes the SSH connection and all sessions on it. NewClient fails
+// afterwards.
+func (s *SSHConn) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	s.sessions = 0
+	if s.client == nil {
+		return nil
+	}
+	err := s.client.Close()
+	s.client = nil
+	return err
+}
+
+func (s *SSHConn) openSession(ctx context.Context) (*sshConnWrapper, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, errSSHConnClosed
+	}
+	client, err := s.connectLocked(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var once sync.Once
+	wrapper, err := openSSHShell(client, func() error {
+		var err error
+		once.Do(func() { err = s.releaseSession(client) })
+		return err
+	})
+	if err != nil {
+		// The connection may have died since the last use; drop it so the
+		// next session dials again.
+		if s.sessions == 0 {
+			_ = client.Close()
+			s.client = nil
+		}
+		return nil, err
+	}
+
+	s.sessions++
+	return wrapper, nil
+}
+
+// connectLocked returns the live SSH connection, dialing when needed.
+func (s *SSHConn) connectLocked(ctx context.Context) (*ssh.Client, error) {
+	if s.client != nil {
+		return s.client, nil
+	}
+
+	clientCfg, cleanup, err := s.sshCfg.clientConfig(s.cfg.Host, s.cfg.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	client, err := dialSSHClient(ctx, s.cfg, s.addr, clientCfg)
+	if err != nil {
+		return nil, err
+	}
+	s.client = client
+
+	go func() {
+		_ = client.Wait()
+		s.mu.Lock()
+		if s.client == client {
+			s.client = nil
+			s.sessions = 0
+		}
+		s.mu.Unlock()
+	}()
+	return client, nil
+}
+
+// releaseSession closes the SSH connection when its last session closed.
+func (s *SSHConn) releaseSession(client *ssh.Client) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.client != client {
+		// The connection was replaced or closed already.
+		return nil
+	}
+	s.sessions--
+	if s.sessions > 0 {
+		return nil
+	}
+	s.client = nil
+	return client.Close()
+}
@@ -0,0 +1,349 @@
+package ts3
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultPollInterval = 5 * time.Second
+
+// PollResources selects what a WebQuery poller watches.
+type PollResources int
+
+const (
+	// PollClients diffs "clientlist -uid -groups" into client enter, leave
+	// and move events.
+	PollClients PollResources = 1 << iota
+	// PollChannels diffs "channellist" into channel created, edited, moved
+	// and deleted events.
+	PollChannels
+	// PollServer diffs "serverinfo" into server edited events.
+	PollServer
+
+	// PollAll watches every resource.
+	PollAll = PollClients | PollChannels | PollServer
+)
+
+// PollPolicy enables synthesized events for WebQuery clients, which cannot
+// receive notifications. The client periodically fetches the watched
+// resources and emits the differences to the previous snapshot as the same
+// notifications the raw transport delivers. Delivery is best effort: changes
+// that are reverted between two polls are missed, and a client that left and
+// reconnected with a new id looks like a leave and an enter.
+type PollPolicy struct {
+	// Interval is the time between polls. Default: 5s.
+	Interval time.Duration
+	// Resources selects the watched resources. 0 means PollAll.
+	Resources PollResources
+}
+
+// volatileServerKeys change on their own and are not reported as edits.
+var volatileServerKeys = []string{
+	"virtualserver_uptime",
+	"virtualserver_clientsonline",
+	"virtualserver_queryclientsonline",
+	"virtualserver_channelsonline",
+	"virtualserver_client_connections",
+	"virtualserver_query_client_connections",
+	"virtualserver_month_bytes_",
+	"virtualserver_total_",
+	"virtualserver_status",
+	"connection_",
+}
+
+// webQueryPoller holds the snapshots of the previous poll. nil maps mean the
+// resource was not fetched yet.
+type webQueryPoller struct {
+	interval  time.Duration
+	resources PollResources
+	sid       int
+	clients   map[string]map[string]string
+	channels  map[string]map[string]string
+	server    map[string]string
+}
+
+func newWebQueryPoller(p PollPolicy) *webQueryPoller {
+	interval := p.Interval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	resources := p.Resources
+	if resources == 0 {
+		resources = PollAll
+	}
+	return &webQueryPoller{interval: interval, resources: resources, sid: -1}
+}
+
+// pollEvents maps the synthesized events to the resource they are diffed
+// from.
+var pollEvents = map[string]PollResources{
+	EventClientEnterView: PollClients,
+	EventClientLeftView:  PollClients,
+	EventClientMoved:     PollClients,
+	EventChannelCreated:  PollChannels,
+	EventChannelEdited:   PollChannels,
+	EventChannelMoved:    PollChannels,
+	EventChannelDeleted:  PollChannels,
+	EventServerEdited:    PollServer,
+}
+
+// watchesEvent reports whether the poller delivers eventName.
+func (p *webQueryPoller) watchesEvent(eventName string) bool {
+	res, ok := pollEvents[eventName]
+	return ok && p.resources&res != 0
+}
+
+// watches reports whether the poller delivers at least one event of scope.
+func (p *webQueryPoller) watches(scope NotifyScope) bool {
+	for eventName := range pollEvents {
+		if !p.watchesEvent(eventName) {
+			continue
+		}
+		for _, s := range eventScopes[eventName] {
+			if s.Event == scope.Event {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// pollLoop polls until the client is closed.
+func (c *Client) pollLoop(p *webQueryPoller) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.quit:
+			return
+		default:
+		}
+		c.poll(p)
+
+		select {
+		case <-ticker.C:
+		case <-c.quit:
+			return
+		}
+	}
+}
+
+// poll fetches the watched resources once and dispatches the differences.
+// A resource that fails keeps its previous snapshot.
+func (c *Client) poll(p *webQueryPoller) {
+	ctx, cancel := context.WithTimeout(context.Background(), p.interval)
+	defer cancel()
+
+	// After Use the snapshots belong to another virtual server.
+	c.mu.Lock()
+	sid := c.selectedSID
+	c.mu.Unlock()
+	if sid != p.sid {
+		p.sid = sid
+		p.clients, p.channels, p.server = nil, nil, nil
+	}
+
+	fetch := func(res PollResources, cmd string) ([]map[string]string, bool) {
+		if p.resources&res == 0 {
+			return nil, false
+		}
+		var rows []map[string]string
+		resp, err := c.Exec(ctx, cmd)
+		if err == nil {
+			err = NewDecoder().Decode(resp, &rows)
+		}
+		if err != nil {
+			if !isEmptyResult(err) {
+				c.logf("ts3: webquery poll %s: %v", commandName(cmd), err)
+				return nil, false
+			}
+			rows = nil
+		}
+		return rows, true
+	}
+
+	channelRows, channelsOK := fetch(PollChannels, "channellist")
+	serverRows, serverOK := fetch(PollServer, "serverinfo")
+	clientRows, clientsOK := fetch(PollClients, "clientlist -uid -groups")
+
+	var lines, deleted []string
+	if channelsOK {
+		channels := indexRows(channelRows, "cid")
+		if p.channels != nil {
+			var changed []string
+			changed, deleted = diffChannels(p.channels, channels)
+			lines = append(lines, changed...)
+		}
+		p.channels = channels
+	}
+	if serverOK && len(serverRows) > 0 {
+		if p.server != nil {
+			lines = append(lines, diffServer(p.server, serverRows[0])...)
+		}
+		p.server = serverRows[0]
+	}
+	if clientsOK {
+		clients := indexRows(clientRows, "clid")
+		if p.clients != nil {
+			lines = append(lines, diffClients(p.clients, clients)...)
+		}
+		p.clients = clients
+	}
+	lines = append(lines, deleted...)
+
+	for _, line := range lines {
+		select {
+		case <-c.quit:
+			return
+		default:
+		}
+		c.dispatchNotify(line)
+	}
+}
+
+func isEmptyResult(err error) bool {
+	var qerr *Error
+	return errors.As(err, &qerr) && qerr.Is(ErrDatabaseEmptyResult)
+}
+
+func indexRows(rows []map[string]string, key string) map[string]map[string]string {
+	out := make(map[string]map[string]string, len(rows))
+	for _, row := range rows {
+		if id := row[key]; id != "" {
+			out[id] = row
+		}
+	}
+	return out
+}
+
+// diffChannels returns created, moved and edited notifications, and the
+// deletions separately, so they can be sent after clients left the channels.
+func diffChannels(prev, cur map[string]map[string]string) (changed, deleted []string) {
+	for _, cid := range sortedIDs(cur) {
+		row := cur[cid]
+		old, ok := prev[cid]
+		if !ok {
+			params := []string{"cid=" + cid, "cpid=" + Escape(row["pid"]), "channel_order=" + Escape(row["channel_order"])}
+			params = append(params, channelProps(row, nil)...)
+			changed = append(changed, notifyLine(EventChannelCreated, params))
+			continue
+		}
+		if old["pid"] != row["pid"] || old["channel_order"] != row["channel_order"] {
+			changed = append(changed, notifyLine(EventChannelMoved, []string{
+				"cid=" + cid,
+				"cpid=" + Escape(row["pid"]),
+				"order=" + Escape(row["channel_order"]),
+				"reasonid=1",
+			}))
+		}
+		if props := channelProps(row, old); len(props) > 0 {
+			params := append([]string{"cid=" + cid, "reasonid=10"}, props...)
+			changed = append(changed, notifyLine(EventChannelEdited, params))
+		}
+	}
+	for _, cid := range sortedIDs(prev) {
+		if _, ok := cur[cid]; !ok {
+			deleted = append(deleted, notifyLine(EventChannelDeleted, []string{"cid=" + cid}))
+		}
+	}
+	return changed, deleted
+}
+
+// channelProps returns the channel_* properties of row that differ from old.
+// The order is reported by channel moves.
+func channelProps(row, old map[string]string) []string {
+	var params []string
+	for _, key := range slices.Sorted(maps.Keys(row)) {
+		if !strings.HasPrefix(key, "channel_") || key == "channel_order" {
+			continue
+		}
+		if old != nil && old[key] == row[key] {
+			continue
+		}
+		params = append(params, key+"="+Escape(row[key]))
+	}
+	return params
+}
+
+func diffServer(prev, cur map[string]string) []string {
+	var params []string
+	for _, key := range slices.Sorted(maps.Keys(cur)) {
+		if !strings.HasPrefix(key, "virtualserver_") || isVolatileServerKey(key) || prev[key] == cur[key] {
+			continue
+		}
+		params = append(params, key+"="+Escape(cur[key]))
+	}
+	if len(params) == 0 {
+		return nil
+	}
+	return []string{notifyLine(EventServerEdited, append([]string{"reasonid=10"}, params...))}
+}
+
+func isVolatileServerKey(key string) bool {
+	for _, prefix := range volatileServerKeys {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func diffClients(prev, cur map[string]map[string]string) []string {
+	var lines []string
+	for _, clid := range sortedIDs(cur) {
+		row := cur[clid]
+		old, ok := prev[clid]
+		switch {
+		case !ok:
+			params := []string{"cfid=0", "ctid=" + Escape(row["cid"]), "reasonid=0", "clid=" + clid}
+			for _, key := range slices.Sorted(maps.Keys(row)) {
+				if key != "clid" && key != "cid" {
+					params = append(params, key+"="+Escape(row[key]))
+				}
+			}
+			lines = append(lines, notifyLine(EventClientEnterView, params))
+		case old["cid"] != row["cid"]:
+			lines = append(lines, notifyLine(EventClientMoved, []string{
+				"ctid=" + Escape(row["cid"]),
+				"reasonid=0",
+				"clid=" + clid,
+			}))
+		}
+	}
+	for _, clid := range sortedIDs(prev) {
+		if _, ok := cur[clid]; !ok {
+			lines = append(lines, notifyLine(EventClientLeftView, []string{
+				"cfid=" + Escape(prev[clid]["cid"]),
+				"ctid=0",
+				"reasonid=8",
+				"clid=" + clid,
+			}))
+		}
+	}
+	return lines
+}
+
+// sortedIDs returns the numeric ids of m in ascending order.
+func sortedIDs(m map[string]map[string]string) []string {
+	ids := slices.Collect(maps.Keys(m))
+	sort.Slice(ids, func(i, j int) bool {
+		a, errA := strconv.Atoi(ids[i])
+		b, errB := strconv.Atoi(ids[j])
+		if errA != nil || errB != nil {
+			return ids[i] < ids[j]
+		}
+		return a < b
+	})
+	return ids
+}
+
+func notifyLine(event string, params []string) string {
+	return event + " " + strings.Join(params, " ")
+}
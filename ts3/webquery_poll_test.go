@@ -0,0 +1,136 @@
+package ts3
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jkesh/ts3-go/v2/ts3/models"
+)
+
+func TestWebQueryPollerSynthesizesEvents(t *testing.T) {
+	var mu sync.Mutex
+	channels := []map[string]interface{}{
+		{"cid": "1", "pid": "0", "channel_order": "0", "channel_name": "Lobby", "total_clients": "2"},
+		{"cid": "2", "pid": "0", "channel_order": "1", "channel_name": "AFK", "total_clients": "0"},
+	}
+	clients := []map[string]interface{}{
+		{"clid": "5", "cid": "1", "client_nickname": "Alice", "client_type": "0"},
+		{"clid": "6", "cid": "1", "client_nickname": "Bob", "client_type": "0"},
+	}
+	server := []map[string]interface{}{
+		{"virtualserver_name": "Test", "virtualserver_uptime": "10"},
+	}
+
+	client, srv := newWebQueryTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/1/channellist":
+			writeWebQueryOK(t, w, channels)
+		case "/1/clientlist":
+			writeWebQueryOK(t, w, clients)
+		case "/1/serverinfo":
+			writeWebQueryOK(t, w, server)
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+	}, 1)
+	defer srv.Close()
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if _, err := client.OnClientMoved(ctx, func(models.ClientMoved) {}); !errors.Is(err, errWebQueryNotifyUnsupported) {
+		t.Fatalf("expected unsupported error without poller, got %v", err)
+	}
+
+	poller := newWebQueryPoller(PollPolicy{})
+	client.web.poller = poller
+
+	if err := client.RegisterTextEvents(ctx); !errors.Is(err, errWebQueryNotifyUnsupported) {
+		t.Fatalf("expected unsupported error for text events, got %v", err)
+	}
+	events := client.Events(ctx, EventFilter{})
+
+	client.poll(poller) // baseline, no events
+
+	mu.Lock()
+	channels = []map[string]interface{}{
+		{"cid": "1", "pid": "0", "channel_order": "0", "channel_name": "Lobby", "total_clients": "1"},
+		{"cid": "3", "pid": "1", "channel_order": "0", "channel_name": "Music", "total_clients": "1"},
+	}
+	clients = []map[string]interface{}{
+		{"clid": "5", "cid": "3", "client_nickname": "Alice", "client_type": "0"},
+		{"clid": "7", "cid": "1", "client_nickname": "Carol", "client_type": "0"},
+	}
+	server = []map[string]interface{}{
+		{"virtualserver_name": "Renamed", "virtualserver_uptime": "15"},
+	}
+	mu.Unlock()
+
+	client.poll(poller)
+
+	want := []string{
+		"notifychannelcreated cid=3 cpid=1 channel_order=0 channel_name=Music",
+		"notifyserveredited reasonid=10 virtualserver_name=Renamed",
+		"notifyclientmoved ctid=3 reasonid=0 clid=5",
+		"notifycliententerview cfid=0 ctid=1 reasonid=0 clid=7 client_nickname=Carol client_type=0",
+		"notifyclientleftview cfid=1 ctid=0 reasonid=8 clid=6",
+		"notifychanneldeleted cid=2",
+	}
+	for _, line := range want {
+		select {
+		case ev := <-events:
+			if got := ev.Name + " " + ev.Data; got != line {
+				t.Fatalf("unexpected event:\n got=%q\nwant=%q", got, line)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("event %q not delivered", line)
+		}
+	}
+	select {
+	case ev := <-events:
+		t.Fatalf("unexpected extra event: %+v", ev)
+	default:
+	}
+
+	var enter models.ClientEnterView
+	client.dispatchNotify(want[3])
+	if err := (<-events).Decode(&enter); err != nil || enter.ID != 7 || enter.Nickname != "Carol" || enter.ToChannelID != 1 {
+		t.Fatalf("unexpected decoded enter event: %+v err=%v", enter, err)
+	}
+}
+
+func TestWebQueryPollerRejectsUnpolledEvents(t *testing.T) {
+	client, srv := newWebQueryTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeWebQueryOK(t, w, []map[string]interface{}{})
+	}, 1)
+	defer srv.Close()
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	// Client events come from clientlist only, channel changes from
+	// channellist only.
+	client.web.poller = newWebQueryPoller(PollPolicy{Resources: PollServer})
+	if _, err := client.OnClientEnterView(ctx, func(models.ClientEnterView) {}); !errors.Is(err, errWebQueryNotifyUnsupported) {
+		t.Fatalf("expected unsupported error for client events, got %v", err)
+	}
+	if _, err := client.OnServerEdited(ctx, func(models.ServerEdited) {}); err != nil {
+		t.Fatalf("OnServerEdited failed: %v", err)
+	}
+
+	client.web.poller = newWebQueryPoller(PollPolicy{Resources: PollClients})
+	if _, err := client.OnChannelCreated(ctx, func(models.ChannelCreated) {}); !errors.Is(err, errWebQueryNotifyUnsupported) {
+		t.Fatalf("expected unsupported error for channel events, got %v", err)
+	}
+	if _, err := client.OnClientMoved(ctx, func(models.ClientMoved) {}); err != nil {
+		t.Fatalf("OnClientMoved failed: %v", err)
+	}
+}